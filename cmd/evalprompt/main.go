@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/justinndidit/job-agent/internal/eval"
+	"github.com/justinndidit/job-agent/internal/logger"
+	"github.com/rs/zerolog"
+	"google.golang.org/genai"
+)
+
+func main() {
+	golden := flag.String("golden", "eval/golden.jsonl", "golden set (.jsonl or .yaml)")
+	backend := flag.String("backend", "gemini", "LLM backend: gemini, replay or rules")
+	model := flag.String("model", agent.DefaultModel, "model name passed to the backend")
+	record := flag.String("record", "", "append live responses to this recordings file (gemini backend)")
+	replay := flag.String("replay", "eval/recordings.jsonl", "recordings file (replay backend)")
+	out := flag.String("out", "eval/last_run.json", "where to write this run's report")
+	previous := flag.String("previous", "", "report to diff against (defaults to -out before it is overwritten)")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout")
+	flag.Parse()
+
+	log := logger.NewLoggerWithService("evalprompt").Level(zerolog.WarnLevel)
+
+	cases, err := eval.LoadCases(*golden)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load golden set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var llm agent.LLM
+	var processor eval.QueryProcessor
+	switch *backend {
+	case "gemini":
+		client, err := genai.NewClient(ctx, nil)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create Gemini client")
+		}
+		llm = client.Models
+		if *record != "" {
+			rec, err := agent.NewRecordingLLM(llm, *record)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to open recordings")
+			}
+			defer rec.Close()
+			llm = rec
+		}
+	case "replay":
+		llm, err = agent.LoadReplayLLM(*replay)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "No recordings at %s. Record them first with a live run:\n\n"+
+				"  go run ./cmd/evalprompt -backend gemini -golden %s -record %s\n", *replay, *golden, *replay)
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load recordings")
+		}
+	case "rules":
+		// The same path ProcessQuery takes when every model is down: input
+		// checks, the rule-based parser, then the grounding check
+		*model = "rule-based"
+		quiet := log.Level(zerolog.ErrorLevel)
+		rules := agent.NewGeminiAgentWithLLM(unavailable{}, *model, &quiet)
+		rules.UseFallbackChain(nil, 0, true)
+		processor = rules
+	default:
+		log.Fatal().Str("backend", *backend).Msg("Unknown backend")
+	}
+
+	prevPath := *previous
+	if prevPath == "" {
+		prevPath = *out
+	}
+	prev, err := eval.LoadReport(prevPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load previous report")
+	}
+
+	if processor == nil {
+		processor = agent.NewGeminiAgentWithLLM(llm, *model, &log)
+	}
+	report := eval.Run(ctx, processor, cases)
+	report.Backend = *backend
+	report.Model = *model
+
+	report.Print(os.Stdout)
+	if prev != nil {
+		fmt.Printf("\nChanges since %s:\n", prev.RunAt.Format(time.RFC3339))
+		changes := eval.Diff(prev, report)
+		if len(changes) == 0 {
+			fmt.Println("  none")
+		}
+		for _, c := range changes {
+			fmt.Println("  " + c)
+		}
+	}
+
+	if *out != "" {
+		if err := report.Save(*out); err != nil {
+			log.Fatal().Err(err).Msg("Failed to save report")
+		}
+	}
+}
+
+// unavailable is a backend with every model down.
+type unavailable struct{}
+
+func (unavailable) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	return nil, agent.ErrAllModelsFailed
+}
@@ -14,9 +14,12 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/justinndidit/job-agent/internal/agent"
 	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/justinndidit/job-agent/internal/expand"
 	"github.com/justinndidit/job-agent/internal/handler"
 	"github.com/justinndidit/job-agent/internal/logger"
+	"github.com/justinndidit/job-agent/internal/push"
 	"github.com/justinndidit/job-agent/internal/scraper"
+	"github.com/justinndidit/job-agent/internal/semantic"
 )
 
 func main() {
@@ -29,31 +32,103 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to load config")
 	}
 
-	// if cfg.TelexAPIKey == "" {
-	// 	log.Warn().Msg("TELEX_API_KEY not set - A2A auth disabled")
-	// }
+	if cfg.A2A.APIKey == "" {
+		log.Warn().Msg("TELEX_API_KEY not set - A2A auth disabled")
+	}
 
 	// Initialize components
 	jobScraper := scraper.NewJobScraper(cfg.JobScraper, &log)
 	geminiAgent := agent.NewGeminiAgent(&log)
-	executor := agent.NewExecutor(jobScraper, geminiAgent, &log)
+	geminiAgent.UseFallbackChain(cfg.LLM.Models, cfg.LLM.LatencyBudget, cfg.LLM.RuleBasedFallback)
+	if cfg.LLM.CacheSize > 0 {
+		geminiAgent.UseCache(agent.NewQueryCache(cfg.LLM.CacheTTL, cfg.LLM.CacheSize))
+	}
+	prices, err := agent.ParsePrices(cfg.LLMPrices)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid LLM_PRICES")
+	}
+	usageTracker := agent.NewUsageTracker(prices, &log)
+	geminiAgent.TrackUsage(usageTracker)
+	var searcher agent.JobSearcher = jobScraper
+	var index *semantic.Index
+	if cfg.Semantic.Embedder != "off" {
+		var embedder semantic.Embedder = semantic.NewHashingEmbedder(semantic.DefaultHashDims)
+		if cfg.Semantic.Embedder == "gemini" {
+			if embedder, err = geminiAgent.Embedder(cfg.Semantic.EmbeddingModel); err != nil {
+				log.Fatal().Err(err).Msg("Failed to set up embeddings")
+			}
+		}
+		index = semantic.NewIndex(embedder, cfg.Semantic.IndexFile, cfg.Semantic.MaxEntries, &log)
+		loadCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		if err := index.Load(loadCtx); err != nil {
+			log.Error().Err(err).Msg("Failed to load semantic index, starting empty")
+		}
+		cancel()
+		searcher = index.Wrap(jobScraper)
+	}
+	executor := agent.NewExecutor(searcher, geminiAgent, &log)
+	if index != nil {
+		executor.UseSemanticIndex(index)
+	}
+	executor.UseReranking(cfg.LLM.Rerank)
+	if cfg.LLM.Summaries {
+		var summaryCache *agent.QueryCache
+		if cfg.LLM.SummaryCacheSize > 0 {
+			// Postings rarely change, so summaries outlive query extractions
+			summaryCache = agent.NewQueryCache(7*24*time.Hour, cfg.LLM.SummaryCacheSize)
+		}
+		executor.UseSummaries(summaryCache)
+	}
+	if cfg.LLM.Expansion != "off" {
+		taxonomy, err := expand.LoadTaxonomy(cfg.LLM.TaxonomyFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid title taxonomy")
+		}
+		expander := expand.NewExpander(taxonomy, &log)
+		if cfg.LLM.Expansion == "llm" {
+			expander.UseSuggester(geminiAgent)
+		}
+		executor.UseExpander(expander)
+	}
+	if cfg.Agent.Mode == "tools" {
+		loop := agent.NewToolLoop(geminiAgent, searcher, cfg.Agent.MaxSteps, cfg.Agent.Timeout, &log)
+		if index != nil {
+			loop.UseSemanticIndex(index)
+		}
+		executor.UseToolLoop(loop)
+	}
 
 	// Initialize handlers
 	regularHandler := handler.NewHandler(executor, &log)
-	a2aHandler := handler.NewA2AHandler(executor, &log) //, cfg.TelexAPIKey)
+	a2aHandler := handler.NewA2AHandler(executor, cfg.A2A, &log)
+	adminHandler := handler.NewAdminHandler(usageTracker, geminiAgent, cfg.AdminAPIKey, &log)
+	adminHandler.AllowUnauthenticated(cfg.AdminAllowUnauthenticated)
+	if index != nil {
+		adminHandler.UseIndex(index)
+	}
+	if cfg.Files.FetchURIs {
+		a2aHandler.FetchFileURIs(cfg.Files.FetchTimeout, cfg.Files.AllowPrivateURLs)
+	}
+	var notifier *push.Notifier
+	if cfg.Push.Enabled {
+		notifier = push.NewNotifier(cfg.Push.Workers, cfg.Push.MaxAttempts, cfg.Push.Timeout, cfg.Push.DeadLetterFile, cfg.Push.AllowPrivateURLs, &log)
+		a2aHandler.UsePushNotifications(notifier)
+	}
 
 	// Setup router
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(handler.ClientAddr(cfg.TrustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	// Every route but POST / times out here; HandleA2A applies the same
+	// timeout itself to all methods except the streaming ones
+	timed := r.With(middleware.Timeout(handler.RequestTimeout))
 
 	// Health check
-	r.Get("/health", regularHandler.HealthCheck)
+	timed.Get("/health", regularHandler.HealthCheck)
 
 	// ===== A2A Protocol Endpoints (Telex Standard) =====
 	// These are the REQUIRED endpoints for A2A protocol compliance
@@ -61,20 +136,35 @@ func main() {
 	// 1. Agent Card Discovery - PUBLIC endpoint
 	//    Telex calls this to discover your agent's capabilities
 	//    Must be at: /.well-known/agent.json
-	r.Get("/.well-known/agent.json", a2aHandler.AgentCard)
+	timed.Get("/.well-known/agent.json", a2aHandler.AgentCard)
 
 	// 2. RPC Endpoint - AUTHENTICATED endpoint
 	//    All method calls (message/send, task/subscribe, etc.) go here
 	//    Must be at: / (root)
+	//    message/stream and tasks/resubscribe last as long as their task
 	r.Post("/", a2aHandler.HandleA2A)
 
+	// JSON schemas of the structured query input and job-results output
+	timed.Get(handler.JobQuerySchemaPath, a2aHandler.JobQuerySchema)
+	timed.Get(handler.JobResultsSchemaPath, a2aHandler.JobResultsSchema)
+
 	// ===== Legacy API Routes (Optional - For Testing) =====
 	// You can keep these for backward compatibility or testing
-	r.Route("/api", func(r chi.Router) {
+	timed.Route("/api", func(r chi.Router) {
 		r.Get("/agent-card", regularHandler.AgentCard)
 		r.Post("/search", regularHandler.SearchJobs)
 	})
 
+	// ===== Admin =====
+	timed.Route("/admin", func(r chi.Router) {
+		r.Use(adminHandler.RequireKey)
+		r.Get("/usage", adminHandler.Usage)
+		r.Get("/metrics", adminHandler.Metrics)
+		r.Get("/models", adminHandler.Models)
+		r.Get("/cache", adminHandler.Cache)
+		r.Get("/index", adminHandler.Index)
+	})
+
 	// Server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Port),
@@ -88,7 +178,7 @@ func main() {
 	go func() {
 		log.Info().
 			Str("port", cfg.Port).
-			// Bool("telex_auth", cfg.TelexAPIKey != "").
+			Bool("a2a_auth", cfg.A2A.APIKey != "").
 			Msg("Starting A2A-compliant Job Search Agent")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Server failed")
@@ -108,6 +198,16 @@ func main() {
 		log.Fatal().Err(err).Msg("Server shutdown failed")
 	}
 
+	if notifier != nil {
+		if err := notifier.Close(ctx); err != nil {
+			log.Error().Err(err).Msg("Pending push notifications not delivered")
+		}
+	}
+	if index != nil {
+		if err := index.Save(); err != nil {
+			log.Error().Err(err).Msg("Failed to save semantic index")
+		}
+	}
 	geminiAgent.Close()
 	log.Info().Msg("Server stopped gracefully")
 }
@@ -7,6 +7,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/rs/zerolog v1.34.0
 	google.golang.org/genai v1.33.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
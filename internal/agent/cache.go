@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	DefaultCacheTTL  = time.Hour
+	DefaultCacheSize = 1000
+)
+
+// NormalizeQuery folds case, punctuation and whitespace so equivalent
+// phrasings ("Golang jobs in London!" / "golang jobs  in london") share a key.
+func NormalizeQuery(s string) string {
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#':
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(mapped), " ")
+}
+
+type CacheStats struct {
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"`
+	Expired   int64   `json:"expired"`
+	Size      int     `json:"size"`
+	MaxSize   int     `json:"maxSize"`
+	TTL       string  `json:"ttl"`
+	HitRate   float64 `json:"hitRate"`
+}
+
+type cacheEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+// QueryCache is a bounded LRU of extraction results with a per-entry TTL.
+type QueryCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	order   *list.List
+	entries map[string]*list.Element
+	stats   CacheStats
+}
+
+func NewQueryCache(ttl time.Duration, maxSize int) *QueryCache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultCacheSize
+	}
+	return &QueryCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *QueryCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return "", false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		c.stats.Expired++
+		c.stats.Misses++
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return entry.value, true
+}
+
+func (c *QueryCache) Set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*cacheEntry)
+		entry.value, entry.expiresAt = value, time.Now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expiresAt: time.Now().Add(c.ttl)})
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *QueryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.MaxSize = c.maxSize
+	stats.TTL = c.ttl.String()
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/genai"
+)
+
+const (
+	initRetryMin   = 2 * time.Second
+	initRetryMax   = time.Minute
+	modelCooldown  = 30 * time.Second
+	maxCooldown    = 5 * time.Minute
+	DefaultLatency = 10 * time.Second
+)
+
+var (
+	ErrAllModelsFailed   = errors.New("all models in the fallback chain failed")
+	ErrClientUnavailable = errors.New("gemini client unavailable")
+)
+
+// ClientManager creates the genai client lazily and, unlike a sync.Once,
+// retries after a failed initialization with exponential backoff instead of
+// leaving callers with a nil client.
+type ClientManager struct {
+	mu        sync.Mutex
+	client    *genai.Client
+	lastErr   error
+	nextRetry time.Time
+	backoff   time.Duration
+	newClient func(context.Context) (*genai.Client, error)
+	logger    *zerolog.Logger
+}
+
+func NewClientManager(log *zerolog.Logger) *ClientManager {
+	return &ClientManager{
+		newClient: func(ctx context.Context) (*genai.Client, error) { return genai.NewClient(ctx, nil) },
+		logger:    log,
+	}
+}
+
+func (m *ClientManager) Client(ctx context.Context) (*genai.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client != nil {
+		return m.client, nil
+	}
+	if m.lastErr != nil && time.Now().Before(m.nextRetry) {
+		return nil, fmt.Errorf("%w until %s: %v", ErrClientUnavailable, m.nextRetry.Format(time.RFC3339), m.lastErr)
+	}
+
+	client, err := m.newClient(ctx)
+	if err != nil {
+		if m.backoff == 0 {
+			m.backoff = initRetryMin
+		} else {
+			m.backoff = min(m.backoff*2, initRetryMax)
+		}
+		m.lastErr = err
+		m.nextRetry = time.Now().Add(m.backoff)
+		m.logger.Error().Err(err).Dur("retry_in", m.backoff).Msg("Failed to create Gemini client")
+		return nil, fmt.Errorf("%w: %v", ErrClientUnavailable, err)
+	}
+
+	m.client, m.lastErr, m.backoff = client, nil, 0
+	m.logger.Info().Msg("Gemini client initialized successfully")
+	return client, nil
+}
+
+// ModelHealth is the circuit-breaker state of one model in the chain.
+type ModelHealth struct {
+	Model               string        `json:"model"`
+	ConsecutiveFailures int           `json:"consecutiveFailures"`
+	OpenUntil           time.Time     `json:"openUntil,omitempty"`
+	LastError           string        `json:"lastError,omitempty"`
+	LastLatency         time.Duration `json:"lastLatency"`
+}
+
+// FallbackLLM tries an ordered list of models, skipping ones that recently
+// failed and moving on when a model errors or exceeds the latency budget.
+type FallbackLLM struct {
+	next   LLM
+	models []string
+	budget time.Duration
+	mu     sync.Mutex
+	health map[string]*ModelHealth
+	logger *zerolog.Logger
+}
+
+func NewFallbackLLM(next LLM, models []string, budget time.Duration, log *zerolog.Logger) *FallbackLLM {
+	if budget <= 0 {
+		budget = DefaultLatency
+	}
+	health := make(map[string]*ModelHealth, len(models))
+	for _, m := range models {
+		health[m] = &ModelHealth{Model: m}
+	}
+	return &FallbackLLM{next: next, models: models, budget: budget, health: health, logger: log}
+}
+
+// GenerateContent starts with the requested model and continues down the
+// chain. The parent context's cancellation is never treated as a model
+// failure.
+func (f *FallbackLLM) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	var lastErr error
+	for _, m := range f.order(model) {
+		attemptCtx, cancel := context.WithTimeout(ctx, f.budget)
+		start := time.Now()
+		resp, err := f.next.GenerateContent(attemptCtx, m, contents, config)
+		cancel()
+		elapsed := time.Since(start)
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err == nil {
+			f.markSuccess(m, elapsed)
+			return resp, nil
+		}
+
+		f.markFailure(m, elapsed, err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("%w: %v", ErrAllModelsFailed, lastErr)
+}
+
+// order returns the models to try: healthy ones first in chain order, then
+// those whose circuit is open, so a request is never refused outright.
+func (f *FallbackLLM) order(requested string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	chain := f.models
+	if _, ok := f.health[requested]; !ok && requested != "" {
+		f.health[requested] = &ModelHealth{Model: requested}
+		chain = append([]string{requested}, chain...)
+	}
+
+	now := time.Now()
+	var healthy, open []string
+	for _, m := range chain {
+		if f.health[m].OpenUntil.After(now) {
+			open = append(open, m)
+		} else {
+			healthy = append(healthy, m)
+		}
+	}
+	return append(healthy, open...)
+}
+
+func (f *FallbackLLM) markSuccess(model string, elapsed time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h := f.health[model]
+	if h.ConsecutiveFailures > 0 {
+		f.logger.Info().Str("model", model).Msg("Model recovered")
+	}
+	h.ConsecutiveFailures, h.OpenUntil, h.LastError, h.LastLatency = 0, time.Time{}, "", elapsed
+}
+
+func (f *FallbackLLM) markFailure(model string, elapsed time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h := f.health[model]
+	h.ConsecutiveFailures++
+	h.LastError = err.Error()
+	h.LastLatency = elapsed
+
+	cooldown := modelCooldown << (h.ConsecutiveFailures - 1)
+	if cooldown > maxCooldown || cooldown <= 0 {
+		cooldown = maxCooldown
+	}
+	h.OpenUntil = time.Now().Add(cooldown)
+	f.logger.Warn().Err(err).Str("model", model).Dur("latency", elapsed).Dur("cooldown", cooldown).Msg("Model failed, falling back")
+}
+
+func (f *FallbackLLM) Health() []ModelHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]ModelHealth, 0, len(f.health))
+	for _, m := range f.models {
+		out = append(out, *f.health[m])
+	}
+	return out
+}
@@ -0,0 +1,310 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/genai"
+)
+
+// Model behaviours for fakeModels
+const (
+	modelUp   = "up"
+	modelDown = "down"
+	modelSlow = "slow"
+)
+
+// fakeModels answers per model: up models reply, down ones fail and slow
+// ones block until their context ends. Models default to up.
+type fakeModels struct {
+	mu     sync.Mutex
+	state  map[string]string
+	answer string
+	calls  []string
+}
+
+var errOverloaded = errors.New("503 model overloaded")
+
+func newFakeModels(answer string) *fakeModels {
+	return &fakeModels{state: make(map[string]string), answer: answer}
+}
+
+func (f *fakeModels) set(model, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state[model] = state
+}
+
+// called returns and resets the models tried so far.
+func (f *fakeModels) called() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := strings.Join(f.calls, ",")
+	f.calls = nil
+	return calls
+}
+
+func (f *fakeModels) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, model)
+	state := f.state[model]
+	f.mu.Unlock()
+
+	switch state {
+	case modelDown:
+		return nil, errOverloaded
+	case modelSlow:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return TextResponse(f.answer), nil
+}
+
+func TestClientManagerRetries(t *testing.T) {
+	log := zerolog.Nop()
+	m := NewClientManager(&log)
+	attempts := 0
+	fail := true
+	m.newClient = func(context.Context) (*genai.Client, error) {
+		attempts++
+		if fail {
+			return nil, errors.New("no credentials")
+		}
+		return &genai.Client{}, nil
+	}
+	// retry pretends the backoff has passed
+	retry := func() {
+		m.mu.Lock()
+		m.nextRetry = time.Now().Add(-time.Millisecond)
+		m.mu.Unlock()
+	}
+
+	if _, err := m.Client(context.Background()); !errors.Is(err, ErrClientUnavailable) {
+		t.Fatalf("failed init: %v, want ErrClientUnavailable", err)
+	}
+	if _, err := m.Client(context.Background()); !errors.Is(err, ErrClientUnavailable) || attempts != 1 {
+		t.Fatalf("during backoff: %v after %d attempts, want ErrClientUnavailable after 1", err, attempts)
+	}
+	if m.backoff != initRetryMin {
+		t.Errorf("backoff %v, want %v", m.backoff, initRetryMin)
+	}
+
+	retry()
+	m.Client(context.Background())
+	if attempts != 2 || m.backoff != 2*initRetryMin {
+		t.Errorf("second failure: %d attempts, backoff %v; want 2, %v", attempts, m.backoff, 2*initRetryMin)
+	}
+
+	retry()
+	fail = false
+	client, err := m.Client(context.Background())
+	if err != nil || client == nil {
+		t.Fatalf("init after recovery: %v", err)
+	}
+	if again, _ := m.Client(context.Background()); again != client || attempts != 3 {
+		t.Errorf("client not reused: %d attempts", attempts)
+	}
+	if m.backoff != 0 || m.lastErr != nil {
+		t.Errorf("backoff %v, last error %v after success", m.backoff, m.lastErr)
+	}
+}
+
+func TestFallbackLLMFollowsChain(t *testing.T) {
+	log := zerolog.Nop()
+	models := newFakeModels("ok")
+	f := NewFallbackLLM(models, []string{"lite", "flash", "pro"}, time.Second, &log)
+	generate := func() error {
+		_, err := f.GenerateContent(context.Background(), "lite", genai.Text("hi"), nil)
+		return err
+	}
+
+	if err := generate(); err != nil || models.called() != "lite" {
+		t.Fatalf("healthy chain: %v", err)
+	}
+
+	models.set("lite", modelDown)
+	if err := generate(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := models.called(); calls != "lite,flash" {
+		t.Errorf("first model down: tried %s, want lite,flash", calls)
+	}
+
+	// lite's breaker is open: it is skipped, not retried first
+	if err := generate(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := models.called(); calls != "flash" {
+		t.Errorf("open breaker: tried %s, want flash", calls)
+	}
+
+	models.set("flash", modelDown)
+	models.set("pro", modelDown)
+	err := generate()
+	if !errors.Is(err, ErrAllModelsFailed) || !strings.Contains(err.Error(), errOverloaded.Error()) {
+		t.Errorf("all down: %v, want ErrAllModelsFailed with the last error", err)
+	}
+	// Open models are still tried last rather than refusing the request
+	if calls := models.called(); calls != "flash,pro,lite" {
+		t.Errorf("all down: tried %s, want flash,pro,lite", calls)
+	}
+}
+
+func TestFallbackLLMBreaker(t *testing.T) {
+	log := zerolog.Nop()
+	models := newFakeModels("ok")
+	f := NewFallbackLLM(models, []string{"lite", "flash"}, time.Second, &log)
+	health := func(model string) ModelHealth {
+		for _, h := range f.Health() {
+			if h.Model == model {
+				return h
+			}
+		}
+		t.Fatalf("no health for %s", model)
+		return ModelHealth{}
+	}
+
+	models.set("lite", modelDown)
+	start := time.Now()
+	f.GenerateContent(context.Background(), "lite", genai.Text("hi"), nil)
+	h := health("lite")
+	if h.ConsecutiveFailures != 1 || h.OpenUntil.Sub(start) < modelCooldown || h.LastError != errOverloaded.Error() {
+		t.Fatalf("after one failure: %+v, want open for %v", h, modelCooldown)
+	}
+
+	// Once the cooldown is over lite is tried first again; failing again
+	// doubles the cooldown
+	f.health["lite"].OpenUntil = time.Now().Add(-time.Millisecond)
+	start = time.Now()
+	f.GenerateContent(context.Background(), "lite", genai.Text("hi"), nil)
+	if calls := models.called(); calls != "lite,flash,lite,flash" {
+		t.Errorf("tried %s, want lite,flash twice", calls)
+	}
+	if h := health("lite"); h.ConsecutiveFailures != 2 || h.OpenUntil.Sub(start) < 2*modelCooldown {
+		t.Errorf("after two failures: %+v, want open for %v", h, 2*modelCooldown)
+	}
+
+	for range 10 {
+		f.markFailure("lite", 0, errOverloaded)
+	}
+	if d := time.Until(health("lite").OpenUntil); d > maxCooldown {
+		t.Errorf("cooldown %v, want at most %v", d, maxCooldown)
+	}
+
+	// A recovered model closes its breaker on the first success
+	models.set("lite", modelUp)
+	f.health["lite"].OpenUntil = time.Now().Add(-time.Millisecond)
+	if _, err := f.GenerateContent(context.Background(), "lite", genai.Text("hi"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if h := health("lite"); h.ConsecutiveFailures != 0 || !h.OpenUntil.IsZero() || h.LastError != "" {
+		t.Errorf("after recovery: %+v, want a closed breaker", h)
+	}
+	if health("flash").ConsecutiveFailures != 0 {
+		t.Error("fallback model marked failed")
+	}
+}
+
+func TestFallbackLLMLatencyBudget(t *testing.T) {
+	log := zerolog.Nop()
+	models := newFakeModels("ok")
+	models.set("lite", modelSlow)
+	f := NewFallbackLLM(models, []string{"lite", "flash"}, 20*time.Millisecond, &log)
+
+	start := time.Now()
+	if _, err := f.GenerateContent(context.Background(), "lite", genai.Text("hi"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v with a 20ms budget", elapsed)
+	}
+	if calls := models.called(); calls != "lite,flash" {
+		t.Errorf("tried %s, want lite,flash", calls)
+	}
+	if h := f.Health()[0]; h.ConsecutiveFailures != 1 || !strings.Contains(h.LastError, "deadline") {
+		t.Errorf("slow model: %+v, want a deadline failure", h)
+	}
+
+	// The caller giving up is not the model's fault
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	models.set("flash", modelSlow)
+	if _, err := f.GenerateContent(ctx, "flash", genai.Text("hi"), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("canceled request: %v", err)
+	}
+	if h := f.Health()[1]; h.ConsecutiveFailures != 0 {
+		t.Errorf("flash marked failed by a canceled request: %+v", h)
+	}
+}
+
+func TestRulesOnlyAfterEveryModelFails(t *testing.T) {
+	const message = "nurse jobs in Lagos"
+	log := zerolog.Nop()
+	models := newFakeModels("title: nurse, location: Lagos, language: en")
+	g := NewGeminiAgentWithLLM(models, "", &log)
+	g.UseFallbackChain([]string{"lite", "flash"}, 20*time.Millisecond, true)
+
+	models.set("lite", modelDown)
+	models.set("flash", modelSlow)
+	ruled, err := g.ProcessQuery(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := models.called(); calls != "lite,flash" {
+		t.Errorf("tried %s before the rules, want lite,flash", calls)
+	}
+	if !strings.Contains(ruled, "title: nurse") || strings.Contains(ruled, "language") {
+		t.Errorf("rule-based answer %q", ruled)
+	}
+
+	// One model recovering is enough to skip the rules
+	models.set("flash", modelUp)
+	answer, err := g.ProcessQuery(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(answer, "language: en") {
+		t.Errorf("answer %q, want the model's", answer)
+	}
+	// Both breakers are open, so the chain order still applies
+	if calls := models.called(); calls != "lite,flash" {
+		t.Errorf("tried %s, want lite,flash", calls)
+	}
+
+	// A canceled request fails instead of falling back
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.ProcessQuery(ctx, message); err == nil {
+		t.Error("canceled request answered by the rules")
+	}
+
+	// Without the rules, a chain that fails is an error
+	models.set("flash", modelDown)
+	models.set("lite", modelDown)
+	g.UseFallbackChain(nil, 0, false)
+	if _, err := g.ProcessQuery(context.Background(), "teacher jobs in Nairobi"); !errors.Is(err, ErrAllModelsFailed) {
+		t.Errorf("without rules: %v, want ErrAllModelsFailed", err)
+	}
+}
+
+func TestRulesWhenClientUnavailable(t *testing.T) {
+	log := zerolog.Nop()
+	g := NewGeminiAgent(&log)
+	g.clients.newClient = func(context.Context) (*genai.Client, error) {
+		return nil, errors.New("no credentials")
+	}
+	g.UseFallbackChain(nil, 0, true)
+
+	answer, err := g.ProcessQuery(context.Background(), "data analyst roles in NY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "title: data analyst, location: New York" {
+		t.Errorf("answer %q", answer)
+	}
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/scraper"
+)
+
+const conversationTTL = time.Hour
+
+// Conversation is the state kept per A2A contextId so follow-up messages can
+// refine the previous search instead of starting over.
+type Conversation struct {
+	Query   scraper.JobQuery
+	Results []scraper.JobPosting
+	// Resume is the last resume attached in this context, if any
+	Resume    string
+	UpdatedAt time.Time
+}
+
+type ConversationStore struct {
+	mu            sync.Mutex
+	conversations map[string]*Conversation
+}
+
+func NewConversationStore() *ConversationStore {
+	return &ConversationStore{conversations: make(map[string]*Conversation)}
+}
+
+func (s *ConversationStore) Get(contextID string) (*Conversation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conv, ok := s.conversations[contextID]
+	if !ok || time.Since(conv.UpdatedAt) > conversationTTL {
+		delete(s.conversations, contextID)
+		return nil, false
+	}
+	return conv, true
+}
+
+func (s *ConversationStore) Save(contextID string, query scraper.JobQuery, results []scraper.JobPosting) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, conv := range s.conversations {
+		if now.Sub(conv.UpdatedAt) > conversationTTL {
+			delete(s.conversations, id)
+		}
+	}
+	conv := &Conversation{Query: query, Results: results, UpdatedAt: now}
+	if prev, ok := s.conversations[contextID]; ok {
+		conv.Resume = prev.Resume
+	}
+	s.conversations[contextID] = conv
+}
+
+// SaveResume keeps the resume for later turns, such as cover letters.
+func (s *ConversationStore) SaveResume(contextID, resume string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conv, ok := s.conversations[contextID]; ok {
+		conv.Resume = resume
+	}
+}
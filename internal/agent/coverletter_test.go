@@ -0,0 +1,33 @@
+package agent
+
+import "testing"
+
+func TestParseCoverLetterRequest(t *testing.T) {
+	tests := []struct {
+		message string
+		want    CoverLetterRequest
+		ok      bool
+	}{
+		{"write a cover letter for #2, short and friendly", CoverLetterRequest{Index: 2, Tone: "friendly", Length: "short"}, true},
+		{"cover letter for job id: abc-123", CoverLetterRequest{ID: "abc-123"}, true},
+		{"cover letter, ID=98765", CoverLetterRequest{ID: "98765"}, true},
+		{"cover letter for the first one, any idea how long?", CoverLetterRequest{Index: 1}, true},
+		{"write a short, friendly cover letter for #2", CoverLetterRequest{Index: 2, Tone: "friendly", Length: "short"}, true},
+		{"a detailed cover letter for the 3rd posting", CoverLetterRequest{Index: 3, Length: "long"}, true},
+		{"cover letter for the second job, length: long", CoverLetterRequest{Index: 2, Length: "long"}, true},
+		{"cover letter for the fifth one, keep it brief", CoverLetterRequest{Index: 5, Length: "short"}, true},
+		{"une lettre de motivation courte pour la deuxième", CoverLetterRequest{Index: 2, Length: "short"}, true},
+		{"my first cover letter, how long should it be?", CoverLetterRequest{}, true},
+		{"write a second cover letter, more formal", CoverLetterRequest{Tone: "formal"}, true},
+		{"uma carta de apresentação curta para a primeira", CoverLetterRequest{Index: 1, Length: "short"}, true},
+		{"an ideal cover letter please", CoverLetterRequest{}, true},
+		{"a cover letter about my identity as an engineer", CoverLetterRequest{}, true},
+		{"find backend jobs with id: 5", CoverLetterRequest{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCoverLetterRequest(tt.message)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseCoverLetterRequest(%q) = %+v, %v; want %+v, %v", tt.message, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+const (
+	DefaultEmbeddingModel = "gemini-embedding-001"
+
+	embeddingDims  = 768
+	embedBatchSize = 100
+)
+
+// GeminiEmbedder embeds text with a Gemini embedding model; it satisfies
+// semantic.Embedder.
+type GeminiEmbedder struct {
+	clients *ClientManager
+	model   string
+}
+
+// Embedder returns an embedder sharing the agent's client. It needs the
+// live Gemini client, so it is unavailable on agents built with
+// NewGeminiAgentWithLLM.
+func (g *GeminiAgent) Embedder(model string) (*GeminiEmbedder, error) {
+	if g.clients == nil {
+		return nil, errors.New("embeddings need a Gemini client")
+	}
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	return &GeminiEmbedder{clients: g.clients, model: model}, nil
+}
+
+func (e *GeminiEmbedder) Name() string {
+	return e.model
+}
+
+func (e *GeminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	client, err := e.clients.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dims := int32(embeddingDims)
+	config := &genai.EmbedContentConfig{TaskType: "SEMANTIC_SIMILARITY", OutputDimensionality: &dims}
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+		contents := make([]*genai.Content, 0, end-start)
+		for _, t := range texts[start:end] {
+			contents = append(contents, genai.NewContentFromText(t, genai.RoleUser))
+		}
+
+		resp, err := client.Models.EmbedContent(ctx, e.model, contents, config)
+		if err != nil {
+			return nil, fmt.Errorf("gemini embedding failed: %w", err)
+		}
+		if len(resp.Embeddings) != len(contents) {
+			return nil, fmt.Errorf("gemini returned %d embeddings for %d texts", len(resp.Embeddings), len(contents))
+		}
+		for _, emb := range resp.Embeddings {
+			out = append(out, emb.Values)
+		}
+	}
+	return out, nil
+}
@@ -2,52 +2,406 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/justinndidit/job-agent/internal/expand"
+	"github.com/justinndidit/job-agent/internal/i18n"
 	"github.com/justinndidit/job-agent/internal/scraper"
+	"github.com/justinndidit/job-agent/internal/semantic"
 	"github.com/justinndidit/job-agent/internal/util"
 	"github.com/rs/zerolog"
 )
 
 type AgentExecutor struct {
-	scraper     *scraper.JobScraper
-	geminiAgent *GeminiAgent
-	logger      *zerolog.Logger
+	scraper       JobSearcher
+	geminiAgent   *GeminiAgent
+	conversations *ConversationStore
+	toolLoop      *ToolLoop
+	expander      *expand.Expander
+	index         *semantic.Index
+	rerank        bool
+	summarize     bool
+	summaryCache  *QueryCache
+	logger        *zerolog.Logger
 }
 
-func NewExecutor(scraper *scraper.JobScraper, gemini *GeminiAgent, log *zerolog.Logger) *AgentExecutor {
+// semanticFill is how many results a search should have before postings
+// found by similarity are no longer added.
+const semanticFill = 5
+
+type SearchResult struct {
+	Query   scraper.JobQuery
+	Jobs    []scraper.JobPosting
+	Refined bool
+	// Answer is the model's own reply when the tool loop handled the request.
+	Answer string
+	// Profile is set when the jobs were matched against a resume or a job
+	// description.
+	Profile *ResumeProfile
+	// SimilarTo is the title of the job description the jobs are like.
+	SimilarTo string
+	// Similar counts the trailing Jobs found by similarity among previously
+	// seen postings rather than by the upstream search.
+	Similar int
+}
+
+// ClarificationError is returned when the message does not carry enough
+// information to search. Pending holds whatever was extracted so far so the
+// caller can resume once the user answers Question.
+type ClarificationError struct {
+	Question string
+	Pending  scraper.JobQuery
+}
+
+func (e *ClarificationError) Error() string {
+	return "clarification needed: " + e.Question
+}
+
+func NewExecutor(scraper JobSearcher, gemini *GeminiAgent, log *zerolog.Logger) *AgentExecutor {
 	return &AgentExecutor{
-		scraper:     scraper,
-		geminiAgent: gemini,
-		logger:      log,
+		scraper:       scraper,
+		geminiAgent:   gemini,
+		conversations: NewConversationStore(),
+		logger:        log,
 	}
 }
 
+// UseToolLoop switches Search from single-shot extraction to the tool-calling
+// agent loop.
+func (e *AgentExecutor) UseToolLoop(loop *ToolLoop) {
+	e.toolLoop = loop
+}
+
+// UseExpander searches each title together with its synonyms and related
+// titles.
+func (e *AgentExecutor) UseExpander(x *expand.Expander) {
+	e.expander = x
+}
+
+// UseSemanticIndex tops up searches with few results, and answers messages
+// with no recognizable job title, from postings seen earlier.
+func (e *AgentExecutor) UseSemanticIndex(idx *semantic.Index) {
+	e.index = idx
+}
+
+// UseReranking orders search results by relevance to the user's message,
+// with a reason per job, instead of the upstream order.
+func (e *AgentExecutor) UseReranking(enabled bool) {
+	e.rerank = enabled
+}
+
+// UseSummaries adds a short bullet summary to each result. cache, which may
+// be nil, keeps summaries per job so repeat results cost no model calls.
+func (e *AgentExecutor) UseSummaries(cache *QueryCache) {
+	e.summarize = true
+	e.summaryCache = cache
+}
+
 func (e *AgentExecutor) SearchJobTool(ctx context.Context, userQuery string) ([]scraper.JobPosting, error) {
-	e.logger.Info().Str("query", userQuery).Msg("Processing job search")
+	result, err := e.Search(ctx, "", scraper.JobQuery{}, userQuery)
+	if err != nil {
+		return nil, err
+	}
+	return result.Jobs, nil
+}
+
+// Search runs a job search for userMessage. Within a known contextID the
+// message is treated as a refinement of the previous query; otherwise a fresh
+// query is extracted and merged over pending, the partial query left by an
+// earlier clarification round.
+func (e *AgentExecutor) Search(ctx context.Context, contextID string, pending scraper.JobQuery, userMessage string) (*SearchResult, error) {
+	e.logger.Info().Str("query", userMessage).Str("context_id", contextID).Msg("Processing job search")
 
-	processedMessage, err := e.geminiAgent.ProcessQuery(ctx, userQuery)
+	check, err := CheckInput(userMessage)
 	if err != nil {
-		return nil, fmt.Errorf("failed to process query: %w", err)
+		return nil, err
+	}
+	if check.Injection != "" {
+		e.logger.Warn().Str("match", check.Injection).Str("context_id", contextID).Msg("Rejected message")
+		return nil, ErrPromptInjection
+	}
+	userMessage = check.Text
+	reportProgress(ctx, StageParsing, nil)
+
+	if e.toolLoop != nil {
+		result, err := e.runToolLoop(ctx, contextID, userMessage)
+		if !errors.Is(err, ErrAllModelsFailed) && !errors.Is(err, ErrClientUnavailable) && !errors.Is(err, ErrUngrounded) {
+			return result, err
+		}
+		e.logger.Warn().Err(err).Msg("Agent loop unavailable, falling back to query extraction")
 	}
 
-	if strings.TrimSpace(processedMessage) == "invalid" {
-		return nil, fmt.Errorf("query does not contain valid job search information")
+	var query scraper.JobQuery
+	refined := false
+	if conv, ok := e.conversation(contextID); ok && pending.Title == "" && pending.Location == "" {
+		processed, err := e.geminiAgent.ProcessRefinement(ctx, util.FormatQuery(conv.Query), userMessage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process refinement: %w", err)
+		}
+		if processed == "invalid" {
+			return nil, &ClarificationError{Question: clarifyingQuestion(scraper.JobQuery{Language: conv.Query.Language})}
+		}
+		delta := util.ParseDelta(processed)
+		query = util.ApplyDelta(conv.Query, delta)
+		refined = !delta.New
+	} else {
+		processedMessage, err := e.geminiAgent.ProcessQuery(ctx, userMessage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process query: %w", err)
+		}
+
+		var extracted scraper.JobQuery
+		if strings.TrimSpace(processedMessage) != "invalid" {
+			extracted = util.ParseMessage(processedMessage)
+		}
+		query = mergeQuery(pending, extracted)
+	}
+	if lang := i18n.Detect(userMessage); lang != "" && (query.Language == "" || refined) {
+		query.Language = lang
 	}
 
-	query := util.ParseMessage(processedMessage)
-	if query.Title == "" && query.Location == "" {
-		return nil, fmt.Errorf("could not extract job information")
+	if query.Title == "" {
+		// "roles working on payments infrastructure" has no title, but may
+		// still describe postings we have seen
+		if similar := e.similarJobs(ctx, query, userMessage, nil, semanticFill); len(similar) > 0 {
+			return &SearchResult{Query: query, Jobs: similar, Similar: len(similar)}, nil
+		}
+		return nil, &ClarificationError{Question: clarifyingQuestion(query), Pending: query}
 	}
 
-	e.logger.Info().Str("title", query.Title).Str("location", query.Location).Msg("Parsed query")
+	e.logger.Info().
+		Str("title", query.Title).
+		Str("location", query.Location).
+		Strs("exclude", query.ExcludeOrganizations).
+		Bool("refined", refined).
+		Msg("Parsed query")
+
+	query.Titles = e.expandTitles(ctx, query.Title)
 
+	reportProgress(ctx, StageSearching, nil)
 	jobs, err := e.scraper.QueryJobs(ctx, &query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search jobs: %w", err)
 	}
+	reportProgress(ctx, StageFound, jobs)
 
 	e.logger.Info().Int("count", len(jobs)).Msg("Retrieved jobs")
-	return jobs, nil
+	similar := e.similarJobs(ctx, query, userMessage, jobs, semanticFill-len(jobs))
+	jobs = append(jobs, similar...)
+	if e.rerank || e.summarize {
+		reportProgress(ctx, StageRanking, nil)
+	}
+	if e.rerank {
+		jobs = e.geminiAgent.Rerank(ctx, userMessage, query, jobs)
+	}
+	if e.summarize {
+		jobs = e.geminiAgent.SummarizeJobs(ctx, jobs, query.Language, e.summaryCache)
+	}
+	if contextID != "" {
+		e.conversations.Save(contextID, query, jobs)
+	}
+	return &SearchResult{Query: query, Jobs: jobs, Refined: refined, Similar: len(similar)}, nil
+}
+
+// similarJobs returns up to n indexed postings similar to text that pass
+// query's filters, leaving out those already in have. Errors are logged, not
+// returned: this only ever adds to a search.
+func (e *AgentExecutor) similarJobs(ctx context.Context, query scraper.JobQuery, text string, have []scraper.JobPosting, n int) []scraper.JobPosting {
+	if e.index == nil || n <= 0 {
+		return nil
+	}
+	k := n + len(have)
+	if query.Remote != nil || query.Location != "" || len(query.ExcludeOrganizations) > 0 {
+		// Leave room for the matches the filters drop
+		k *= 3
+	}
+	matches, err := e.index.Search(ctx, text, k, semantic.DefaultMinScore)
+	if err != nil {
+		e.logger.Warn().Err(err).Msg("Semantic search failed")
+		return nil
+	}
+
+	seen := make(map[string]bool, len(have))
+	for _, job := range have {
+		seen[job.Key()] = true
+	}
+	var out []scraper.JobPosting
+	for _, m := range matches {
+		if !seen[m.Job.Key()] && query.Matches(m.Job) && len(out) < n {
+			out = append(out, m.Job)
+		}
+	}
+	if len(out) > 0 {
+		e.logger.Info().Int("count", len(out)).Msg("Added postings found by similarity")
+	}
+	return out
+}
+
+// MatchResume searches for jobs that fit the resume: it extracts a profile,
+// runs one search per suggested title and ranks the combined results by fit.
+// message is the user's accompanying text, used only for the reply language.
+func (e *AgentExecutor) MatchResume(ctx context.Context, contextID, resume, message string) (*SearchResult, error) {
+	e.logger.Info().Int("resume_chars", len(resume)).Str("context_id", contextID).Msg("Processing resume match")
+
+	reportProgress(ctx, StageParsing, nil)
+	profile, err := e.geminiAgent.ExtractProfile(ctx, resume)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resume: %w", err)
+	}
+
+	query, jobs, err := e.searchProfile(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	reportProgress(ctx, StageRanking, nil)
+	ranked := e.geminiAgent.RankJobs(ctx, profile, jobs)
+	query.Language = i18n.Detect(message)
+	if query.Language == "" {
+		query.Language = i18n.Detect(resume)
+	}
+
+	if e.summarize {
+		ranked = e.geminiAgent.SummarizeJobs(ctx, ranked, query.Language, e.summaryCache)
+	}
+
+	e.logger.Info().Strs("titles", profile.Titles).Int("count", len(ranked)).Msg("Ranked resume matches")
+	if contextID != "" {
+		// Follow-ups refine the best-fit title's search
+		e.conversations.Save(contextID, query, ranked)
+		e.conversations.SaveResume(contextID, resume)
+	}
+	return &SearchResult{Query: query, Jobs: ranked, Profile: profile}, nil
+}
+
+// searchProfile runs one search per profile title, dropping postings already
+// found by an earlier one. It returns the first title's query, which
+// follow-ups refine.
+func (e *AgentExecutor) searchProfile(ctx context.Context, profile *ResumeProfile) (scraper.JobQuery, []scraper.JobPosting, error) {
+	queries := profile.Queries()
+	seen := make(map[string]bool)
+	var jobs []scraper.JobPosting
+	reportProgress(ctx, StageSearching, nil)
+	for i := range queries {
+		queries[i].Titles = e.expandTitles(ctx, queries[i].Title)
+		found, err := e.scraper.QueryJobs(ctx, &queries[i])
+		if err != nil {
+			if ctx.Err() != nil {
+				return scraper.JobQuery{}, nil, ctx.Err()
+			}
+			e.logger.Warn().Err(err).Str("title", queries[i].Title).Msg("Profile search failed")
+			continue
+		}
+		var fresh []scraper.JobPosting
+		for _, job := range found {
+			if key := job.Key(); !seen[key] {
+				seen[key] = true
+				fresh = append(fresh, job)
+			}
+		}
+		reportProgress(ctx, StageFound, fresh)
+		jobs = append(jobs, fresh...)
+	}
+	return queries[0], jobs, nil
+}
+
+// SimilarRoles finds jobs like the one a job description describes: the
+// role's titles are searched and ranked by the skills it asks for, and
+// postings with similar text from the semantic index fill up thin results.
+func (e *AgentExecutor) SimilarRoles(ctx context.Context, contextID, description, message string) (*SearchResult, error) {
+	e.logger.Info().Int("description_chars", len(description)).Str("context_id", contextID).Msg("Processing similar roles")
+
+	reportProgress(ctx, StageParsing, nil)
+	profile, err := e.geminiAgent.ExtractJobProfile(ctx, description)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job description: %w", err)
+	}
+	query, jobs, err := e.searchProfile(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	similar := e.similarJobs(ctx, query, description, jobs, semanticFill-len(jobs))
+	jobs = append(jobs, similar...)
+
+	reportProgress(ctx, StageRanking, nil)
+	ranked := e.geminiAgent.RankJobs(ctx, profile, jobs)
+	query.Language = i18n.Detect(message)
+	if query.Language == "" {
+		query.Language = i18n.Detect(description)
+	}
+	if e.summarize {
+		ranked = e.geminiAgent.SummarizeJobs(ctx, ranked, query.Language, e.summaryCache)
+	}
+
+	if contextID != "" {
+		e.conversations.Save(contextID, query, ranked)
+	}
+	return &SearchResult{Query: query, Jobs: ranked, Profile: profile, SimilarTo: profile.Titles[0], Similar: len(similar)}, nil
+}
+
+func (e *AgentExecutor) runToolLoop(ctx context.Context, contextID, userMessage string) (*SearchResult, error) {
+	var history string
+	if conv, ok := e.conversation(contextID); ok {
+		history = "the previous search was " + util.FormatQuery(conv.Query)
+	}
+
+	out, err := e.toolLoop.Run(ctx, userMessage, history)
+	if err != nil {
+		return nil, fmt.Errorf("agent loop failed: %w", err)
+	}
+
+	out.Query.Language = i18n.Detect(userMessage)
+	if contextID != "" && out.Query.Title != "" {
+		e.conversations.Save(contextID, out.Query, out.Jobs)
+	}
+	return &SearchResult{Query: out.Query, Jobs: out.Jobs, Answer: out.Answer}, nil
+}
+
+// expandTitles returns the titles to search for title, or nil to search the
+// title alone.
+func (e *AgentExecutor) expandTitles(ctx context.Context, title string) []string {
+	if e.expander == nil {
+		return nil
+	}
+	titles := e.expander.Expand(ctx, title)
+	if len(titles) < 2 {
+		return nil
+	}
+	e.logger.Debug().Str("title", title).Strs("titles", titles).Msg("Expanded title")
+	return titles
+}
+
+func (e *AgentExecutor) conversation(contextID string) (*Conversation, bool) {
+	if contextID == "" {
+		return nil, false
+	}
+	return e.conversations.Get(contextID)
+}
+
+func mergeQuery(base, update scraper.JobQuery) scraper.JobQuery {
+	if update.Title != "" {
+		base.Title = update.Title
+	}
+	if update.Location != "" {
+		base.Location = update.Location
+	}
+	if update.Remote != nil {
+		base.Remote = update.Remote
+	}
+	if len(update.ExcludeOrganizations) > 0 {
+		base.ExcludeOrganizations = update.ExcludeOrganizations
+	}
+	if update.Language != "" {
+		base.Language = update.Language
+	}
+	return base
+}
+
+func clarifyingQuestion(q scraper.JobQuery) string {
+	if q.Location != "" {
+		return i18n.T(q.Language, "clarify_role_in", q.Location)
+	}
+	return i18n.T(q.Language, "clarify_role")
 }
@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/justinndidit/job-agent/internal/scraper"
+	"github.com/justinndidit/job-agent/internal/semantic"
+	"github.com/rs/zerolog"
+)
+
+func TestSimilarJobsApplyQueryFilters(t *testing.T) {
+	log := zerolog.Nop()
+	index := semantic.NewIndex(semantic.NewHashingEmbedder(semantic.DefaultHashDims), "", 100, &log)
+	indexed := []scraper.JobPosting{
+		{ID: "amazon", Title: "Payments Infrastructure Engineer", Organization: "Amazon", JobLocation: []string{"Berlin, Germany"}, Remote: true},
+		{ID: "onsite", Title: "Payments Infrastructure Engineer", Organization: "Stripe", JobLocation: []string{"Berlin, Germany"}},
+		{ID: "lisbon", Title: "Payments Infrastructure Engineer", Organization: "Adyen", JobLocation: []string{"Lisbon, Portugal"}, Remote: true},
+		{ID: "match", Title: "Payments Infrastructure Engineer", Organization: "Wise", JobLocation: []string{"Berlin, Germany"}, Remote: true},
+	}
+	if err := index.Add(context.Background(), indexed); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExecutor(&fakeSearcher{}, nil, &log)
+	e.UseSemanticIndex(index)
+
+	remote := true
+	query := scraper.JobQuery{Title: "payments engineer", Location: "berlin", Remote: &remote, ExcludeOrganizations: []string{"amazon"}}
+	got := e.similarJobs(context.Background(), query, "payments infrastructure engineer", nil, 5)
+	if len(got) != 1 || got[0].ID != "match" {
+		t.Errorf("similarJobs = %+v, want only the posting passing every filter", got)
+	}
+
+	got = e.similarJobs(context.Background(), scraper.JobQuery{}, "payments infrastructure engineer", nil, 5)
+	if len(got) != len(indexed) {
+		t.Errorf("similarJobs without filters = %d postings, want %d", len(got), len(indexed))
+	}
+}
@@ -0,0 +1,373 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/justinndidit/job-agent/internal/util"
+)
+
+const (
+	MaxInputRunes = 1000
+	maxFieldRunes = 80
+
+	fenceOpen  = "<<<USER_MESSAGE"
+	fenceClose = "USER_MESSAGE>>>"
+)
+
+var (
+	ErrEmptyInput      = errors.New("message is empty")
+	ErrInputTooLong    = fmt.Errorf("message exceeds %d characters", MaxInputRunes)
+	ErrPromptInjection = errors.New("message looks like an attempt to override the agent's instructions")
+
+	injectionPattern = regexp.MustCompile(`(?i)\b(` + strings.Join([]string{
+		`(ignore|disregard|forget|override)\s+(all\s+|any\s+|the\s+|your\s+)*(previous|prior|above|earlier|preceding|system)?\s*(instructions?|prompts?|rules|context)`,
+		`(reveal|print|show|repeat|output)\s+(me\s+)?(your|the)\s+(system\s+)?(prompt|instructions)`,
+		`system\s+prompt`,
+		`you\s+are\s+now\b`,
+		`(developer|god|dan)\s+mode`,
+		`jailbreak`,
+		`new\s+instructions?\s*:`,
+		`(return|respond|reply|answer)\s+(only\s+)?(with\s+)?exactly`,
+		`</?(system|assistant|instructions?)>`,
+	}, "|") + `)`)
+	// rolePattern finds a line starting like a chat transcript turn. Only at
+	// the start of a line: "Customer Success Assistant: remote" is a search.
+	rolePattern  = regexp.MustCompile(`(?im)^[ \t]*(assistant|system)[ \t]*:`)
+	fenceMarkers = strings.NewReplacer(fenceOpen, "", fenceClose, "", "<<<", "", ">>>", "", "```", "")
+)
+
+// InputCheck is the result of screening a user message before it reaches the
+// model. Injection holds the matched phrase when the message looks like an
+// attempt to override the prompt.
+type InputCheck struct {
+	Text      string
+	Injection string
+}
+
+// CheckInput strips control characters and fence delimiters, collapses
+// whitespace, enforces the length limit and looks for injection phrasing.
+// Role markers are looked for before the lines are joined.
+func CheckInput(raw string) (InputCheck, error) {
+	lines := strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\r':
+			return '\n'
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, raw)
+	lines = fenceMarkers.Replace(lines)
+	cleaned := strings.Join(strings.Fields(lines), " ")
+
+	if cleaned == "" {
+		return InputCheck{}, ErrEmptyInput
+	}
+	if len([]rune(cleaned)) > MaxInputRunes {
+		return InputCheck{}, ErrInputTooLong
+	}
+	return InputCheck{Text: cleaned, Injection: findInjection(lines)}, nil
+}
+
+// findInjection returns the first phrase of text that looks like an attempt
+// to override the prompt, or "".
+func findInjection(text string) string {
+	if match := rolePattern.FindString(text); match != "" {
+		return strings.TrimSpace(match)
+	}
+	return injectionPattern.FindString(strings.Join(strings.Fields(text), " "))
+}
+
+// fence wraps untrusted text so the prompt can tell the model to treat it as
+// data rather than instructions.
+func fence(text string) string {
+	return fenceOpen + "\n" + text + "\n" + fenceClose
+}
+
+var abbreviations = map[string][]string{
+	"ny":  {"new york"},
+	"nyc": {"new york"},
+	"sf":  {"san francisco"},
+	"la":  {"los angeles", "louisiana"},
+	"ca":  {"california", "canada"},
+	"tx":  {"texas"},
+	"wa":  {"washington"},
+	"dc":  {"washington"},
+	"uk":  {"united kingdom", "london"},
+	"us":  {"united states"},
+	"usa": {"united states"},
+	"uae": {"united arab emirates", "dubai"},
+	"swe": {"software engineer", "software developer"},
+	"sde": {"software engineer", "software developer"},
+	"pm":  {"product manager", "project manager"},
+	"qa":  {"quality assurance"},
+	"ml":  {"machine learning"},
+	"sre": {"site reliability"},
+	"ux":  {"user experience"},
+	"dev": {"developer"},
+	"wfh": {"remote"},
+}
+
+// translations are the English words a title or location word in one of
+// the supported languages may be translated to. A translated field is only
+// accepted when each of its words is the source word itself, unaccented,
+// or listed here.
+var translations = map[string][]string{
+	// fr
+	"developpeur": {"developer"}, "ingenieur": {"engineer"}, "logiciel": {"software"},
+	"infirmier": {"nurse"}, "infirmiere": {"nurse"}, "enseignant": {"teacher"}, "professeur": {"teacher"},
+	"comptable": {"accountant"}, "vendeur": {"sales", "salesperson"}, "chef": {"chef", "head", "manager"},
+	"donnees": {"data"}, "analyste": {"analyst"}, "medecin": {"doctor", "physician"}, "chauffeur": {"driver"},
+	"londres": {"london"}, "bruxelles": {"brussels"}, "geneve": {"geneva"}, "allemagne": {"germany"},
+	"espagne": {"spain"}, "angleterre": {"england"}, "etats": {"united", "states"}, "unis": {"united", "states"},
+	// pt
+	"desenvolvedor": {"developer"}, "programador": {"programmer", "developer"}, "engenheiro": {"engineer"},
+	"enfermeiro": {"nurse"}, "enfermeira": {"nurse"}, "professor": {"teacher"}, "professora": {"teacher"},
+	"contador": {"accountant"}, "vendedor": {"sales", "salesperson"}, "dados": {"data"}, "analista": {"analyst"},
+	"motorista": {"driver"}, "lisboa": {"lisbon"}, "alemanha": {"germany"}, "espanha": {"spain"},
+	// es
+	"desarrollador": {"developer"}, "ingeniero": {"engineer"}, "enfermera": {"nurse"}, "enfermero": {"nurse"},
+	"maestro": {"teacher"}, "maestra": {"teacher"}, "datos": {"data"}, "conductor": {"driver"},
+	"nueva": {"new"}, "alemania": {"germany"}, "espana": {"spain"}, "ciudad": {"city"},
+	// pt, es
+	"medico": {"doctor", "physician"},
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "î", "i", "ï", "i", "ñ", "n", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ú", "u", "û", "u", "ü", "u",
+)
+
+var remoteWords = []string{"remote", "wfh", "anywhere", "work from home", "home office"}
+
+// plausible reports whether an extracted value could have come from input:
+// at least one of its words of 3 or more letters (or a known expansion of an
+// input abbreviation) must be a word of the message. Shorter words, such as
+// "in", only count when they are the whole value, as with "Go" or "C#".
+func plausible(input, value string) bool {
+	if value == "" {
+		return true
+	}
+	if len([]rune(value)) > maxFieldRunes || strings.Contains(value, "://") || strings.ContainsAny(value, "<>{}") {
+		return false
+	}
+
+	in := strings.ToLower(input)
+	val := strings.ToLower(value)
+	if val == "remote" || strings.Contains(val, "remote") {
+		for _, w := range remoteWords {
+			if strings.Contains(in, w) {
+				return true
+			}
+		}
+	}
+
+	inWords := words(in)
+	for _, w := range inWords {
+		for _, expansion := range abbreviations[w] {
+			if strings.Contains(val, expansion) {
+				return true
+			}
+		}
+	}
+
+	valWords := words(val)
+	short := true
+	for _, w := range valWords {
+		if len([]rune(w)) >= 3 {
+			short = false
+		}
+	}
+	for _, w := range valWords {
+		if len([]rune(w)) < 3 && !short {
+			continue
+		}
+		for _, iw := range inWords {
+			if sameWord(w, iw) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameWord reports whether two words are equal, tolerating inflection of
+// words of 5 letters or more: "teachers" and "teacher", "developing" and
+// "developer".
+func sameWord(a, b string) bool {
+	return a == b || inflects(a, b) || inflects(b, a)
+}
+
+// inflects reports whether b starts with a's stem: a without its last two
+// letters.
+func inflects(a, b string) bool {
+	r := []rune(a)
+	return len(r) >= 5 && strings.HasPrefix(b, string(r[:len(r)-2]))
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+}
+
+// validateExtraction drops fields the model produced that are not plausibly
+// grounded in the user's message. It returns "invalid" if nothing survives.
+func validateExtraction(input, output string) (string, []string) {
+	if output == "invalid" {
+		return output, nil
+	}
+
+	query := util.ParseMessage(output)
+	sources := fieldSources(output)
+	var rejected []string
+	if !grounded(input, query.Title, sources["title"]) {
+		rejected = append(rejected, "title")
+		query.Title = ""
+	}
+	if !grounded(input, query.Location, sources["location"]) {
+		rejected = append(rejected, "location")
+		query.Location = ""
+	}
+	query.ExcludeOrganizations = plausibleList(input, query.ExcludeOrganizations)
+
+	if query.Title == "" && query.Location == "" {
+		return "invalid", rejected
+	}
+	return util.FormatQuery(query), rejected
+}
+
+// validateDelta applies the same grounding check to a refinement.
+func validateDelta(input, output string) (string, []string) {
+	if output == "invalid" {
+		return output, nil
+	}
+
+	delta := util.ParseDelta(output)
+	sources := fieldSources(output)
+	var rejected []string
+	if !grounded(input, delta.Title, sources["title"]) {
+		rejected = append(rejected, "title")
+		delta.Title = ""
+	}
+	if !strings.EqualFold(delta.Location, "any") && !grounded(input, delta.Location, sources["location"]) {
+		rejected = append(rejected, "location")
+		delta.Location = ""
+	}
+	delta.Exclude = plausibleList(input, delta.Exclude)
+	delta.Include = plausibleList(input, delta.Include)
+	return formatDelta(delta), rejected
+}
+
+// fieldSources reads the "title_source" and "location_source" fields: the
+// words of the message a translated title or location was translated from.
+func fieldSources(output string) map[string]string {
+	sources := make(map[string]string)
+	for _, field := range strings.Split(output, ",") {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		if name, ok := strings.CutSuffix(strings.ToLower(strings.TrimSpace(key)), "_source"); ok {
+			sources[name] = strings.TrimSpace(value)
+		}
+	}
+	return sources
+}
+
+// grounded reports whether value is plausible for input, or else a known
+// translation of its source words, which must themselves be in the input.
+func grounded(input, value, source string) bool {
+	if plausible(input, value) {
+		return true
+	}
+	if source == "" || len([]rune(source)) > maxFieldRunes {
+		return false
+	}
+
+	in := strings.ToLower(input)
+	sourceWords := words(strings.ToLower(source))
+	for _, w := range sourceWords {
+		if !strings.Contains(in, w) {
+			return false
+		}
+	}
+	return translatedFrom(strings.ToLower(value), sourceWords)
+}
+
+// translatedFrom reports whether every word of value is one of the source
+// words without accents, or a listed translation or abbreviation of one.
+func translatedFrom(value string, sourceWords []string) bool {
+	valueWords := words(value)
+	if len(valueWords) == 0 {
+		return false
+	}
+	for _, vw := range valueWords {
+		if !justified(vw, sourceWords) {
+			return false
+		}
+	}
+	return true
+}
+
+func justified(valueWord string, sourceWords []string) bool {
+	for _, sw := range sourceWords {
+		folded := accents.Replace(sw)
+		if folded == valueWord {
+			return true
+		}
+		candidates := [][]string{translations[folded], abbreviations[sw]}
+		// Plurals, e.g. "développeurs", "enfermeiras"
+		if stem, ok := strings.CutSuffix(folded, "s"); ok {
+			candidates = append(candidates, translations[stem])
+		}
+		for _, list := range candidates {
+			for _, expansion := range list {
+				if slices.Contains(words(expansion), valueWord) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func plausibleList(input string, values []string) []string {
+	var kept []string
+	for _, v := range values {
+		if plausible(input, v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func formatDelta(d util.QueryDelta) string {
+	var fields []string
+	if d.New {
+		fields = append(fields, "new")
+	}
+	if d.Title != "" {
+		fields = append(fields, "title: "+d.Title)
+	}
+	if d.Location != "" {
+		fields = append(fields, "location: "+d.Location)
+	}
+	if d.Remote != "" {
+		fields = append(fields, "remote: "+d.Remote)
+	}
+	if len(d.Exclude) > 0 {
+		fields = append(fields, "exclude: "+strings.Join(d.Exclude, "; "))
+	}
+	if len(d.Include) > 0 {
+		fields = append(fields, "include: "+strings.Join(d.Include, "; "))
+	}
+	return strings.Join(fields, ", ")
+}
@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateExtractionTranslations(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		output   string
+		want     string
+		rejected []string
+	}{
+		{
+			name:   "english fields are matched word for word",
+			input:  "software engineer job in SF",
+			output: "title: software engineer, location: San Francisco, language: en",
+			want:   "title: software engineer, location: San Francisco, language: en",
+		},
+		{
+			name:   "translated fields with their sources",
+			input:  "emplois de développeur à Montréal",
+			output: "title: developer, location: Montreal, language: fr, title_source: développeur, location_source: Montréal",
+			want:   "title: developer, location: Montreal, language: fr",
+		},
+		{
+			name:   "plural and multi-word translations",
+			input:  "vagas de enfermeiras em Lisboa",
+			output: "title: nurse, location: Lisbon, language: pt, title_source: enfermeiras, location_source: Lisboa",
+			want:   "title: nurse, location: Lisbon, language: pt",
+		},
+		{
+			name:     "a source does not vouch for unrelated fields",
+			input:    "jobs in Lisbon",
+			output:   "title: CEO, location: Mars, source: jobs",
+			want:     "invalid",
+			rejected: []string{"title", "location"},
+		},
+		{
+			name:     "source words must translate to the value",
+			input:    "jobs in Lisbon",
+			output:   "title: CEO, location: Lisbon, title_source: jobs",
+			want:     "title: , location: Lisbon",
+			rejected: []string{"title"},
+		},
+		{
+			name:     "a title source does not justify the location",
+			input:    "emplois de développeur à Montréal",
+			output:   "title: developer, location: Paris, language: fr, title_source: développeur, location_source: développeur",
+			want:     "title: developer, location: , language: fr",
+			rejected: []string{"location"},
+		},
+		{
+			name:     "source words must be in the message",
+			input:    "vagas em Lisboa",
+			output:   "title: nurse, location: Lisbon, language: pt, title_source: enfermeiro, location_source: Lisboa",
+			want:     "title: , location: Lisbon, language: pt",
+			rejected: []string{"title"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rejected := validateExtraction(tt.input, tt.output)
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(rejected, tt.rejected) {
+				t.Errorf("rejected = %v, want %v", rejected, tt.rejected)
+			}
+		})
+	}
+}
+
+func TestValidateDeltaTranslations(t *testing.T) {
+	got, rejected := validateDelta("et à Londres plutôt ?", "location: London, location_source: Londres")
+	if got != "location: London" || len(rejected) != 0 {
+		t.Errorf("validateDelta = %q, %v", got, rejected)
+	}
+
+	got, rejected = validateDelta("et à Londres plutôt ?", "title: CEO, location: London, location_source: Londres")
+	if got != "location: London" || !slices.Equal(rejected, []string{"title"}) {
+		t.Errorf("validateDelta = %q, %v", got, rejected)
+	}
+}
+
+// TestCheckInputAdversarialCorpus runs the screening half of the
+// adversarial corpus offline: messages expected to be invalid must be
+// flagged before any model call, and the look-alikes must not be.
+func TestCheckInputAdversarialCorpus(t *testing.T) {
+	f, err := os.Open("../../eval/adversarial.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	type corpusCase struct {
+		ID       string `json:"id"`
+		Input    string `json:"input"`
+		Expected struct {
+			Invalid bool `json:"invalid"`
+		} `json:"expected"`
+	}
+	var cases []corpusCase
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var c corpusCase
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		t.Fatal("empty corpus")
+	}
+
+	// More benign messages that share words with injection phrasing
+	for i, input := range []string{
+		"Executive Assistant: London",
+		"Administrative assistant jobs, system: SAP",
+		"teaching assistant roles in Leeds",
+		"instructional designer jobs",
+		"rules engine developer in Paris",
+		"show me the jobs in Berlin",
+		"prompt engineer roles, ignore agencies",
+		"data engineer jobs, new grad",
+	} {
+		cases = append(cases, corpusCase{ID: fmt.Sprintf("look-alike-%d", i+1), Input: input})
+	}
+
+	for _, c := range cases {
+		t.Run(c.ID, func(t *testing.T) {
+			check, err := CheckInput(c.Input)
+			flagged := err != nil || check.Injection != ""
+			if flagged != c.Expected.Invalid {
+				t.Errorf("CheckInput(%q) = %+v, %v; want flagged = %v", c.Input, check, err, c.Expected.Invalid)
+			}
+		})
+	}
+}
+
+func TestPlausible(t *testing.T) {
+	tests := []struct {
+		input, value string
+		want         bool
+	}{
+		{"software engineer jobs in Berlin", "software engineer", true},
+		{"teachers wanted in Leeds", "teacher", true},
+		{"developing apps in Lagos", "developer", true},
+		{"nurse jobs in NYC", "New York", true},
+		{"Go jobs", "Go", true},
+		{"C# developer", "C#", true},
+		{"remote work from home", "remote", true},
+		{"jobs in Lisbon", "Manager in Mars", false},
+		{"data analyst roles", "Ana", false},
+		{"javascript developer", "Java", false},
+		{"jobs in Lisbon", "CEO", false},
+	}
+	for _, tt := range tests {
+		if got := plausible(tt.input, tt.value); got != tt.want {
+			t.Errorf("plausible(%q, %q) = %v, want %v", tt.input, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// LLM is the subset of the genai Models service the agent depends on.
+// *genai.Models satisfies it, as do the replay and recording backends below.
+type LLM interface {
+	GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+}
+
+var ErrNoRecording = errors.New("no recorded response for request")
+
+type recording struct {
+	Key      string                         `json:"key"`
+	Model    string                         `json:"model"`
+	Prompt   string                         `json:"prompt"`
+	Response *genai.GenerateContentResponse `json:"response"`
+}
+
+func recordingKey(model string, contents []*genai.Content, config *genai.GenerateContentConfig) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(model))
+	for _, v := range []any{contents, config} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash request: %w", err)
+		}
+		h.Write([]byte{'\n'})
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func promptText(contents []*genai.Content) string {
+	var sb strings.Builder
+	for _, c := range contents {
+		for _, p := range c.Parts {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
+
+// ReplayLLM serves responses previously captured by a RecordingLLM, so prompt
+// evaluations can run offline and deterministically.
+type ReplayLLM struct {
+	responses map[string]*genai.GenerateContentResponse
+}
+
+func LoadReplayLLM(path string) (*ReplayLLM, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recordings: %w", err)
+	}
+	defer f.Close()
+
+	r := &ReplayLLM{responses: make(map[string]*genai.GenerateContentResponse)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rec recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("recordings line %d: %w", line, err)
+		}
+		r.responses[rec.Key] = rec.Response
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recordings: %w", err)
+	}
+	return r, nil
+}
+
+func (r *ReplayLLM) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	key, err := recordingKey(model, contents, config)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := r.responses[key]
+	if !ok {
+		return nil, fmt.Errorf("%w (model %s, key %s)", ErrNoRecording, model, key[:12])
+	}
+	return resp, nil
+}
+
+// RecordingLLM forwards requests to another backend and appends every
+// successful response to a JSONL file readable by LoadReplayLLM.
+type RecordingLLM struct {
+	next LLM
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewRecordingLLM(next LLM, path string) (*RecordingLLM, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recordings: %w", err)
+	}
+	return &RecordingLLM{next: next, file: f}, nil
+}
+
+func (r *RecordingLLM) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	resp, err := r.next.GenerateContent(ctx, model, contents, config)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := recordingKey(model, contents, config)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(recording{Key: key, Model: model, Prompt: promptText(contents), Response: resp})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode recording: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write recording: %w", err)
+	}
+	return resp, nil
+}
+
+func (r *RecordingLLM) Close() error {
+	return r.file.Close()
+}
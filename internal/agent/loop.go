@@ -0,0 +1,450 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/scraper"
+	"github.com/justinndidit/job-agent/internal/semantic"
+	"github.com/rs/zerolog"
+	"google.golang.org/genai"
+)
+
+const (
+	DefaultMaxSteps    = 6
+	DefaultLoopTimeout = 45 * time.Second
+
+	maxDescriptionChars = 2000
+)
+
+var (
+	ErrStepLimit = errors.New("agent step limit reached without a final answer")
+	// ErrUngrounded is returned when the model answers without searching,
+	// even after being told to search first.
+	ErrUngrounded = errors.New("agent answered without searching for jobs")
+)
+
+const searchFirstPrompt = `You have not searched yet, so your answer cannot be grounded in real postings. Call search_jobs (or search_saved_jobs) first. If you need more information from the user, reply with only a short clarifying question.`
+
+// JobSearcher is the upstream job search the tools call into.
+// *scraper.JobScraper satisfies it.
+type JobSearcher interface {
+	QueryJobs(ctx context.Context, job *scraper.JobQuery) ([]scraper.JobPosting, error)
+}
+
+// ToolLoop lets the model plan its own searches through declared tools,
+// calling them repeatedly until it produces a final answer or runs out of
+// steps.
+type ToolLoop struct {
+	gemini   *GeminiAgent
+	searcher JobSearcher
+	index    *semantic.Index
+	maxSteps int
+	timeout  time.Duration
+	logger   *zerolog.Logger
+}
+
+type ToolCall struct {
+	Name   string         `json:"name"`
+	Args   map[string]any `json:"args"`
+	Error  string         `json:"error,omitempty"`
+	Result map[string]any `json:"-"`
+}
+
+type LoopResult struct {
+	Answer string
+	Jobs   []scraper.JobPosting
+	Calls  []ToolCall
+	Query  scraper.JobQuery
+}
+
+func NewToolLoop(gemini *GeminiAgent, searcher JobSearcher, maxSteps int, timeout time.Duration, log *zerolog.Logger) *ToolLoop {
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	if timeout <= 0 {
+		timeout = DefaultLoopTimeout
+	}
+	return &ToolLoop{gemini: gemini, searcher: searcher, maxSteps: maxSteps, timeout: timeout, logger: log}
+}
+
+const toolSystemPrompt = `You are a job search assistant. Use the tools to find real job postings.
+- Always call search_jobs before recommending jobs; never invent postings, companies or links.
+- Refer to jobs by the "index" returned by the tools.
+- Use filter_results, get_job_details and compare_jobs to narrow down or explain results.
+- Tool arguments must be in English; translate titles and locations if needed.
+- Answer in the language the user wrote in.
+- When you have enough information, answer concisely in plain text, citing title, company, location and URL for each job you mention.
+- If the user's message is not about jobs or is missing the role, ask a short clarifying question instead of searching.`
+
+// UseSemanticIndex adds a search_saved_jobs tool that finds previously seen
+// postings by meaning.
+func (l *ToolLoop) UseSemanticIndex(idx *semantic.Index) {
+	l.index = idx
+}
+
+func (l *ToolLoop) tools() []*genai.Tool {
+	tools := toolDeclarations()
+	if l.index != nil {
+		tools[0].FunctionDeclarations = append(tools[0].FunctionDeclarations, &genai.FunctionDeclaration{
+			Name:        "search_saved_jobs",
+			Description: "Find postings seen in earlier searches by meaning, e.g. \"teams working on payments infrastructure\". Use when the request describes the work rather than a job title.",
+			Parameters: &genai.Schema{
+				Type:       genai.TypeObject,
+				Properties: map[string]*genai.Schema{"description": {Type: genai.TypeString, Description: "What the job involves"}},
+				Required:   []string{"description"},
+			},
+		})
+	}
+	return tools
+}
+
+func toolDeclarations() []*genai.Tool {
+	str := func(desc string) *genai.Schema { return &genai.Schema{Type: genai.TypeString, Description: desc} }
+	indices := &genai.Schema{Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeInteger}, Description: "Result indices as returned by search_jobs"}
+
+	return []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+		{
+			Name:        "search_jobs",
+			Description: "Search the job board by title and optional location. Results are added to the session and numbered.",
+			Parameters: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"title":    str("Job title, e.g. \"backend engineer\""),
+					"location": str("City, region or country; omit for anywhere"),
+					"remote":   {Type: genai.TypeBoolean, Description: "Only remote jobs"},
+				},
+				Required: []string{"title"},
+			},
+		},
+		{
+			Name:        "get_job_details",
+			Description: "Get the full posting, including the description, for one result.",
+			Parameters: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"index": {Type: genai.TypeInteger, Description: "Result index"},
+					"id":    str("Job id, if known instead of the index"),
+				},
+			},
+		},
+		{
+			Name:        "filter_results",
+			Description: "Filter the results found so far in this session.",
+			Parameters: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"remote":               {Type: genai.TypeBoolean, Description: "Keep only remote (true) or on-site (false) jobs"},
+					"location_contains":    str("Keep jobs whose location contains this text"),
+					"title_contains":       str("Keep jobs whose title contains this text"),
+					"exclude_organization": str("Drop jobs from this company"),
+				},
+			},
+		},
+		{
+			Name:        "compare_jobs",
+			Description: "Compare two or more results side by side.",
+			Parameters: &genai.Schema{
+				Type:       genai.TypeObject,
+				Properties: map[string]*genai.Schema{"indices": indices},
+				Required:   []string{"indices"},
+			},
+		},
+	}}}
+}
+
+// loopSession holds the results the tools have surfaced during one Run.
+type loopSession struct {
+	results  []scraper.JobPosting
+	selected []int
+	query    scraper.JobQuery
+	// searched is set once a search tool has returned
+	searched bool
+}
+
+// Run drives the model until it returns text instead of function calls.
+// history is an optional note about earlier turns in the conversation.
+// Answers must follow a search: a question asked before any search is
+// returned as a *ClarificationError, other text gets the model told to
+// search first, and ErrUngrounded if it still does not.
+func (l *ToolLoop) Run(ctx context.Context, userMessage, history string) (*LoopResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	llm, err := l.gemini.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := userMessage
+	if history != "" {
+		prompt = fmt.Sprintf("Earlier in this conversation: %s\n\nUser: %s", history, userMessage)
+	}
+	contents := []*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)}
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(toolSystemPrompt, genai.RoleUser),
+		Tools:             l.tools(),
+	}
+
+	session := &loopSession{}
+	result := &LoopResult{}
+	reminded := false
+	for step := 0; step < l.maxSteps; step++ {
+		resp, err := llm.GenerateContent(ctx, l.gemini.model, contents, config)
+		if err != nil {
+			return nil, fmt.Errorf("gemini generation failed at step %d: %w", step+1, err)
+		}
+
+		calls := resp.FunctionCalls()
+		if len(calls) == 0 && !session.searched {
+			text := strings.TrimSpace(resp.Text())
+			if strings.HasSuffix(text, "?") {
+				return nil, &ClarificationError{Question: text}
+			}
+			if reminded {
+				return nil, ErrUngrounded
+			}
+			l.logger.Debug().Int("step", step+1).Msg("Agent answered without searching, asking it to search")
+			reminded = true
+			contents = append(contents,
+				genai.NewContentFromText(text, genai.RoleModel),
+				genai.NewContentFromText(searchFirstPrompt, genai.RoleUser))
+			continue
+		}
+		if len(calls) == 0 {
+			result.Answer = strings.TrimSpace(resp.Text())
+			result.Jobs = session.answerJobs()
+			result.Query = session.query
+			l.logger.Info().Int("steps", step+1).Int("tool_calls", len(result.Calls)).Msg("Agent loop finished")
+			return result, nil
+		}
+
+		if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+			contents = append(contents, resp.Candidates[0].Content)
+		} else {
+			contents = append(contents, genai.NewContentFromParts(functionCallParts(calls), genai.RoleModel))
+		}
+
+		var responses []*genai.Part
+		for _, call := range calls {
+			output, err := l.callTool(ctx, session, call)
+			record := ToolCall{Name: call.Name, Args: call.Args, Result: output}
+			if err != nil {
+				record.Error = err.Error()
+				output = map[string]any{"error": err.Error()}
+			}
+			l.logger.Debug().Str("tool", call.Name).Interface("args", call.Args).Str("error", record.Error).Msg("Tool called")
+			result.Calls = append(result.Calls, record)
+
+			part := genai.NewPartFromFunctionResponse(call.Name, output)
+			part.FunctionResponse.ID = call.ID
+			responses = append(responses, part)
+		}
+		contents = append(contents, genai.NewContentFromParts(responses, genai.RoleUser))
+	}
+
+	return nil, ErrStepLimit
+}
+
+func functionCallParts(calls []*genai.FunctionCall) []*genai.Part {
+	parts := make([]*genai.Part, 0, len(calls))
+	for _, call := range calls {
+		parts = append(parts, &genai.Part{FunctionCall: call})
+	}
+	return parts
+}
+
+func (l *ToolLoop) callTool(ctx context.Context, s *loopSession, call *genai.FunctionCall) (map[string]any, error) {
+	switch call.Name {
+	case "search_jobs":
+		query := scraper.JobQuery{Title: argString(call.Args, "title"), Location: argString(call.Args, "location")}
+		if v, ok := call.Args["remote"].(bool); ok && v {
+			query.Remote = &v
+		}
+		if query.Title == "" {
+			return nil, errors.New("title is required")
+		}
+		reportProgress(ctx, StageSearching, nil)
+		jobs, err := l.searcher.QueryJobs(ctx, &query)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+		reportProgress(ctx, StageFound, jobs)
+		s.searched = true
+		s.query = query
+		start := len(s.results)
+		s.results = append(s.results, jobs...)
+		s.selected = nil
+		for i := range jobs {
+			s.selected = append(s.selected, start+i)
+		}
+		return map[string]any{"count": len(jobs), "results": s.summaries(s.selected)}, nil
+
+	case "search_saved_jobs":
+		if l.index == nil {
+			return nil, errors.New("saved job search is not enabled")
+		}
+		description := argString(call.Args, "description")
+		if description == "" {
+			return nil, errors.New("description is required")
+		}
+		matches, err := l.index.Search(ctx, description, 10, semantic.DefaultMinScore)
+		if err != nil {
+			return nil, fmt.Errorf("saved job search failed: %w", err)
+		}
+		s.searched = true
+		start := len(s.results)
+		s.selected = nil
+		for i, m := range matches {
+			s.results = append(s.results, m.Job)
+			s.selected = append(s.selected, start+i)
+		}
+		return map[string]any{"count": len(matches), "results": s.summaries(s.selected)}, nil
+
+	case "get_job_details":
+		i, err := s.lookup(call.Args)
+		if err != nil {
+			return nil, err
+		}
+		job := s.results[i]
+		details := jobSummary(i, job)
+		details["description"] = truncate(job.Description, maxDescriptionChars)
+		details["employment_type"] = job.EmploymentType
+		details["date_posted"] = job.DatePosted
+		details["valid_through"] = job.DateValidThrough
+		details["organization_url"] = job.OrganizationUrl
+		return details, nil
+
+	case "filter_results":
+		if len(s.results) == 0 {
+			return nil, errors.New("no results yet; call search_jobs first")
+		}
+		var kept []int
+		for i, job := range s.results {
+			if matchesFilter(job, call.Args) {
+				kept = append(kept, i)
+			}
+		}
+		s.selected = kept
+		return map[string]any{"count": len(kept), "results": s.summaries(kept)}, nil
+
+	case "compare_jobs":
+		raw, _ := call.Args["indices"].([]any)
+		if len(raw) < 2 {
+			return nil, errors.New("compare_jobs needs at least two indices")
+		}
+		var rows []map[string]any
+		var picked []int
+		for _, v := range raw {
+			i, ok := argIndex(v)
+			if !ok || i < 0 || i >= len(s.results) {
+				return nil, fmt.Errorf("unknown result index %v", v)
+			}
+			row := jobSummary(i, s.results[i])
+			row["employment_type"] = s.results[i].EmploymentType
+			row["date_posted"] = s.results[i].DatePosted
+			rows = append(rows, row)
+			picked = append(picked, i)
+		}
+		s.selected = picked
+		return map[string]any{"jobs": rows}, nil
+	}
+
+	return nil, fmt.Errorf("unknown tool %q", call.Name)
+}
+
+func (s *loopSession) summaries(indices []int) []map[string]any {
+	out := make([]map[string]any, 0, len(indices))
+	for _, i := range indices {
+		out = append(out, jobSummary(i, s.results[i]))
+	}
+	return out
+}
+
+func (s *loopSession) lookup(args map[string]any) (int, error) {
+	if id := argString(args, "id"); id != "" {
+		for i, job := range s.results {
+			if string(job.ID) == id {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no result with id %q", id)
+	}
+	i, ok := argIndex(args["index"])
+	if !ok || i < 0 || i >= len(s.results) {
+		return 0, fmt.Errorf("unknown result index %v", args["index"])
+	}
+	return i, nil
+}
+
+// answerJobs returns the postings the model last looked at, which is what
+// its final answer is grounded in.
+func (s *loopSession) answerJobs() []scraper.JobPosting {
+	jobs := make([]scraper.JobPosting, 0, len(s.selected))
+	for _, i := range s.selected {
+		jobs = append(jobs, s.results[i])
+	}
+	return jobs
+}
+
+func jobSummary(index int, job scraper.JobPosting) map[string]any {
+	return map[string]any{
+		"index":        index,
+		"id":           string(job.ID),
+		"title":        job.Title,
+		"organization": job.Organization,
+		"locations":    job.JobLocation,
+		"remote":       job.Remote,
+		"url":          job.SourceUrl,
+	}
+}
+
+func matchesFilter(job scraper.JobPosting, args map[string]any) bool {
+	if remote, ok := args["remote"].(bool); ok && job.Remote != remote {
+		return false
+	}
+	if v := strings.ToLower(argString(args, "location_contains")); v != "" &&
+		!strings.Contains(strings.ToLower(strings.Join(job.JobLocation, " ")), v) {
+		return false
+	}
+	if v := strings.ToLower(argString(args, "title_contains")); v != "" && !strings.Contains(strings.ToLower(job.Title), v) {
+		return false
+	}
+	if v := strings.ToLower(argString(args, "exclude_organization")); v != "" && strings.Contains(strings.ToLower(job.Organization), v) {
+		return false
+	}
+	return true
+}
+
+func argString(args map[string]any, key string) string {
+	s, _ := args[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// argIndex accepts the numeric forms an index can take after JSON decoding.
+func argIndex(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
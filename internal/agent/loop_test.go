@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/scraper"
+	"github.com/rs/zerolog"
+	"google.golang.org/genai"
+)
+
+// fakeSearcher returns jobs for every query and records the queries.
+type fakeSearcher struct {
+	jobs    []scraper.JobPosting
+	queries []scraper.JobQuery
+}
+
+func (f *fakeSearcher) QueryJobs(ctx context.Context, job *scraper.JobQuery) ([]scraper.JobPosting, error) {
+	f.queries = append(f.queries, *job)
+	return f.jobs, nil
+}
+
+// llmFunc adapts a function to the LLM interface.
+type llmFunc func(ctx context.Context, contents []*genai.Content) (*genai.GenerateContentResponse, error)
+
+func (f llmFunc) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	return f(ctx, contents)
+}
+
+func newTestLoop(llm LLM, searcher JobSearcher, maxSteps int, timeout time.Duration) *ToolLoop {
+	log := zerolog.Nop()
+	return NewToolLoop(NewGeminiAgentWithLLM(llm, "", &log), searcher, maxSteps, timeout, &log)
+}
+
+var testJobs = []scraper.JobPosting{
+	{ID: "1", Title: "Backend Engineer", Organization: "Acme", JobLocation: []string{"Berlin"}},
+	{ID: "2", Title: "Go Developer", Organization: "Globex", JobLocation: []string{"Berlin"}, Remote: true},
+}
+
+// functionResponses returns the tool outputs sent back in a request.
+func functionResponses(contents []*genai.Content) []*genai.FunctionResponse {
+	var out []*genai.FunctionResponse
+	for _, c := range contents {
+		for _, p := range c.Parts {
+			if p.FunctionResponse != nil {
+				out = append(out, p.FunctionResponse)
+			}
+		}
+	}
+	return out
+}
+
+func TestToolLoopFinalAnswer(t *testing.T) {
+	llm := NewScriptedLLM(
+		FunctionCallResponse("search_jobs", map[string]any{"title": "backend engineer", "location": "Berlin"}),
+		FunctionCallResponse("filter_results", map[string]any{"remote": true}),
+		TextResponse("Go Developer at Globex (remote)."),
+	)
+	searcher := &fakeSearcher{jobs: testJobs}
+
+	result, err := newTestLoop(llm, searcher, 6, time.Minute).Run(context.Background(), "remote backend jobs in Berlin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Answer != "Go Developer at Globex (remote)." {
+		t.Errorf("Answer = %q", result.Answer)
+	}
+	if len(result.Jobs) != 1 || result.Jobs[0].ID != "2" {
+		t.Errorf("Jobs = %+v, want the filtered remote job", result.Jobs)
+	}
+	if result.Query.Title != "backend engineer" || result.Query.Location != "Berlin" {
+		t.Errorf("Query = %+v", result.Query)
+	}
+	if len(result.Calls) != 2 {
+		t.Errorf("Calls = %+v, want 2", result.Calls)
+	}
+	if len(searcher.queries) != 1 {
+		t.Errorf("searched %d times, want 1", len(searcher.queries))
+	}
+	if got := functionResponses(llm.Requests[2]); len(got) != 2 || got[1].Response["count"] != 1 {
+		t.Errorf("tool outputs sent to the model = %+v", got)
+	}
+}
+
+func TestToolLoopStepLimit(t *testing.T) {
+	search := FunctionCallResponse("search_jobs", map[string]any{"title": "backend engineer"})
+	llm := NewScriptedLLM(search, search, search)
+
+	_, err := newTestLoop(llm, &fakeSearcher{jobs: testJobs}, 2, time.Minute).Run(context.Background(), "backend jobs", "")
+	if !errors.Is(err, ErrStepLimit) {
+		t.Fatalf("err = %v, want ErrStepLimit", err)
+	}
+	if len(llm.Requests) != 2 {
+		t.Errorf("model called %d times, want 2", len(llm.Requests))
+	}
+}
+
+func TestToolLoopTimeout(t *testing.T) {
+	llm := llmFunc(func(ctx context.Context, contents []*genai.Content) (*genai.GenerateContentResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	start := time.Now()
+	_, err := newTestLoop(llm, &fakeSearcher{}, 6, 20*time.Millisecond).Run(context.Background(), "backend jobs", "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run took %v after a 20ms timeout", elapsed)
+	}
+}
+
+func TestToolLoopToolErrors(t *testing.T) {
+	llm := NewScriptedLLM(
+		FunctionCallResponse("search_jobs", map[string]any{"location": "Berlin"}),
+		FunctionCallResponse("get_job_details", map[string]any{"index": 7}),
+		FunctionCallResponse("search_jobs", map[string]any{"title": "backend engineer"}),
+		TextResponse("Backend Engineer at Acme."),
+	)
+
+	result, err := newTestLoop(llm, &fakeSearcher{jobs: testJobs}, 6, time.Minute).Run(context.Background(), "jobs in Berlin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Calls[0].Error == "" || result.Calls[1].Error == "" || result.Calls[2].Error != "" {
+		t.Errorf("Calls = %+v, want the first two to fail", result.Calls)
+	}
+	got := functionResponses(llm.Requests[1])
+	if len(got) != 1 || got[0].Response["error"] != "title is required" {
+		t.Errorf("tool output sent to the model = %+v, want the error", got)
+	}
+	if result.Answer != "Backend Engineer at Acme." {
+		t.Errorf("Answer = %q", result.Answer)
+	}
+}
+
+func TestToolLoopGrounding(t *testing.T) {
+	t.Run("answer before searching is re-prompted", func(t *testing.T) {
+		llm := NewScriptedLLM(
+			TextResponse("Acme is hiring backend engineers."),
+			FunctionCallResponse("search_jobs", map[string]any{"title": "backend engineer"}),
+			TextResponse("Backend Engineer at Acme."),
+		)
+		result, err := newTestLoop(llm, &fakeSearcher{jobs: testJobs}, 6, time.Minute).Run(context.Background(), "backend jobs", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Answer != "Backend Engineer at Acme." || len(result.Jobs) != 2 {
+			t.Errorf("result = %+v", result)
+		}
+		last := llm.Requests[1][len(llm.Requests[1])-1]
+		if last.Parts[0].Text != searchFirstPrompt {
+			t.Errorf("re-prompt = %q", last.Parts[0].Text)
+		}
+	})
+
+	t.Run("repeated ungrounded answer is rejected", func(t *testing.T) {
+		llm := NewScriptedLLM(
+			TextResponse("Acme is hiring backend engineers."),
+			TextResponse("Acme is still hiring."),
+		)
+		_, err := newTestLoop(llm, &fakeSearcher{jobs: testJobs}, 6, time.Minute).Run(context.Background(), "backend jobs", "")
+		if !errors.Is(err, ErrUngrounded) {
+			t.Fatalf("err = %v, want ErrUngrounded", err)
+		}
+	})
+
+	t.Run("question before searching asks the user", func(t *testing.T) {
+		llm := NewScriptedLLM(TextResponse("Which role are you looking for?"))
+		_, err := newTestLoop(llm, &fakeSearcher{}, 6, time.Minute).Run(context.Background(), "find me something", "")
+		var clarify *ClarificationError
+		if !errors.As(err, &clarify) || clarify.Question != "Which role are you looking for?" {
+			t.Fatalf("err = %v, want a clarification", err)
+		}
+	})
+}
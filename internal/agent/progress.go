@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/justinndidit/job-agent/internal/scraper"
+)
+
+// Stages reported through WithProgress
+const (
+	StageParsing   = "parsing"
+	StageSearching = "searching"
+	StageFound     = "found"
+	StageRanking   = "ranking"
+	StageDrafting  = "drafting"
+)
+
+// Progress is a step of a running request. Jobs holds the postings that
+// just arrived, in the found stage.
+type Progress struct {
+	Stage string
+	Jobs  []scraper.JobPosting
+}
+
+type progressKey struct{}
+
+// WithProgress makes the executor report each stage of a request run under
+// ctx to fn, as it happens.
+func WithProgress(ctx context.Context, fn func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func reportProgress(ctx context.Context, stage string, jobs []scraper.JobPosting) {
+	if fn, ok := ctx.Value(progressKey{}).(func(Progress)); ok {
+		fn(Progress{Stage: stage, Jobs: jobs})
+	}
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// ScriptedLLM replays a fixed sequence of responses in order and records the
+// requests it was given. It stands in for Gemini when exercising the tool
+// loop without network access.
+type ScriptedLLM struct {
+	mu        sync.Mutex
+	responses []*genai.GenerateContentResponse
+	Requests  [][]*genai.Content
+}
+
+var ErrScriptExhausted = errors.New("scripted model has no more responses")
+
+func NewScriptedLLM(responses ...*genai.GenerateContentResponse) *ScriptedLLM {
+	return &ScriptedLLM{responses: responses}
+}
+
+func (s *ScriptedLLM) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Requests = append(s.Requests, contents)
+	if len(s.responses) == 0 {
+		return nil, ErrScriptExhausted
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+// TextResponse is a scripted final answer.
+func TextResponse(text string) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{
+		{Content: genai.NewContentFromText(text, genai.RoleModel)},
+	}}
+}
+
+// FunctionCallResponse is a scripted turn in which the model calls one tool.
+func FunctionCallResponse(name string, args map[string]any) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{
+		{Content: genai.NewContentFromFunctionCall(name, args, genai.RoleModel)},
+	}}
+}
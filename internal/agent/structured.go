@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/justinndidit/job-agent/internal/i18n"
+	"github.com/justinndidit/job-agent/internal/scraper"
+)
+
+const (
+	// MaxQueryPage bounds paging; results that deep are rarely relevant
+	MaxQueryPage = 10
+
+	maxQueryTitles   = 5
+	maxQueryExcluded = 20
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+// CleanQuery checks a query given as data instead of text, with the limits
+// extracted queries get: a title is required, at most maxQueryTitles other
+// titles may come with it and every value must be a short plain phrase. Unlike in JobQuery, where 0 means the first page, the
+// page must be given, from 1 to MaxQueryPage as in schemas/job-query.json.
+func CleanQuery(q scraper.JobQuery) (scraper.JobQuery, error) {
+	clean := func(field, v string) (string, error) {
+		v = strings.Join(strings.Fields(v), " ")
+		if len([]rune(v)) > maxFieldRunes || strings.Contains(v, "://") || strings.ContainsAny(v, "<>{}\"|") {
+			return "", fmt.Errorf("%w: %s must be a plain phrase of at most %d characters", ErrInvalidQuery, field, maxFieldRunes)
+		}
+		return v, nil
+	}
+
+	var err error
+	if q.Title, err = clean("title", q.Title); err != nil {
+		return q, err
+	}
+	if q.Location, err = clean("location", q.Location); err != nil {
+		return q, err
+	}
+
+	if len(q.Titles) > maxQueryTitles {
+		return q, fmt.Errorf("%w: at most %d titles besides title", ErrInvalidQuery, maxQueryTitles)
+	}
+	var titles []string
+	seen := make(map[string]bool)
+	for _, t := range append([]string{q.Title}, q.Titles...) {
+		if t, err = clean("titles", t); err != nil {
+			return q, err
+		}
+		if key := strings.ToLower(t); t != "" && !seen[key] {
+			seen[key] = true
+			titles = append(titles, t)
+		}
+	}
+	if len(titles) == 0 {
+		return q, fmt.Errorf("%w: title is required", ErrInvalidQuery)
+	}
+	q.Title = titles[0]
+	q.Titles = nil
+	if len(titles) > 1 {
+		q.Titles = titles
+	}
+
+	if len(q.ExcludeOrganizations) > maxQueryExcluded {
+		return q, fmt.Errorf("%w: at most %d excluded organizations", ErrInvalidQuery, maxQueryExcluded)
+	}
+	for i, org := range q.ExcludeOrganizations {
+		if q.ExcludeOrganizations[i], err = clean("excludeOrganizations", org); err != nil {
+			return q, err
+		}
+	}
+
+	if q.Page < 1 || q.Page > MaxQueryPage {
+		return q, fmt.Errorf("%w: page must be between 1 and %d", ErrInvalidQuery, MaxQueryPage)
+	}
+	q.Language = i18n.Normalize(q.Language)
+	return q, nil
+}
+
+// SearchQuery runs a query checked by CleanQuery as is: no model call
+// extracts, expands, ranks or summarizes it, so the same query always gives
+// the same search for the cost of one job board call.
+func (e *AgentExecutor) SearchQuery(ctx context.Context, contextID string, query scraper.JobQuery) (*SearchResult, error) {
+	e.logger.Info().
+		Str("title", query.Title).
+		Str("location", query.Location).
+		Int("page", query.Page).
+		Str("context_id", contextID).
+		Msg("Processing structured job search")
+
+	reportProgress(ctx, StageSearching, nil)
+	jobs, err := e.scraper.QueryJobs(ctx, &query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search jobs: %w", err)
+	}
+	reportProgress(ctx, StageFound, jobs)
+
+	if contextID != "" {
+		e.conversations.Save(contextID, query, jobs)
+	}
+	return &SearchResult{Query: query, Jobs: jobs}, nil
+}
@@ -0,0 +1,269 @@
+package agent
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/genai"
+)
+
+const (
+	recentUsageRecords = 500
+	maxUsageContexts   = 10000
+)
+
+// usageMetrics publishes running token and cost totals through expvar
+// (served at /admin/metrics).
+var usageMetrics = expvar.NewMap("llm_usage")
+
+// ModelPrice is the USD price per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"inputPerMillion"`
+	OutputPerMillion float64 `json:"outputPerMillion"`
+}
+
+var DefaultPrices = map[string]ModelPrice{
+	"gemini-2.5-flash-lite": {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gemini-2.5-flash":      {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+	"gemini-2.5-pro":        {InputPerMillion: 1.25, OutputPerMillion: 10.00},
+}
+
+// ParsePrices reads "model=input/output,..." with prices per million tokens,
+// e.g. "gemini-2.5-flash-lite=0.10/0.40". Entries override DefaultPrices.
+func ParsePrices(spec string) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice, len(DefaultPrices))
+	for model, p := range DefaultPrices {
+		prices[model] = p
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, rates, ok := strings.Cut(entry, "=")
+		in, out, ok2 := strings.Cut(rates, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid price %q, want model=input/output", entry)
+		}
+		inPrice, err := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input price in %q: %w", entry, err)
+		}
+		outPrice, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output price in %q: %w", entry, err)
+		}
+		prices[strings.TrimSpace(model)] = ModelPrice{InputPerMillion: inPrice, OutputPerMillion: outPrice}
+	}
+	return prices, nil
+}
+
+// UsageScope identifies who an LLM call is billed to.
+type UsageScope struct {
+	RequestID string `json:"requestId,omitempty"`
+	Caller    string `json:"caller,omitempty"`
+	ContextID string `json:"contextId,omitempty"`
+}
+
+type usageScopeKey struct{}
+
+func WithUsageScope(ctx context.Context, scope UsageScope) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+func usageScopeFrom(ctx context.Context) UsageScope {
+	scope, _ := ctx.Value(usageScopeKey{}).(UsageScope)
+	return scope
+}
+
+type UsageRecord struct {
+	Time  time.Time `json:"time"`
+	Model string    `json:"model"`
+	UsageScope
+	PromptTokens    int64   `json:"promptTokens"`
+	CandidateTokens int64   `json:"candidateTokens"`
+	TotalTokens     int64   `json:"totalTokens"`
+	CostUSD         float64 `json:"costUsd"`
+	// Unpriced marks calls to a model missing from the prices, costed at 0
+	Unpriced bool `json:"unpriced,omitempty"`
+}
+
+type UsageTotals struct {
+	Calls           int64   `json:"calls"`
+	PromptTokens    int64   `json:"promptTokens"`
+	CandidateTokens int64   `json:"candidateTokens"`
+	TotalTokens     int64   `json:"totalTokens"`
+	CostUSD         float64 `json:"costUsd"`
+	// UnpricedCalls are left out of CostUSD
+	UnpricedCalls int64     `json:"unpricedCalls,omitempty"`
+	LastSeen      time.Time `json:"lastSeen"`
+}
+
+func (t *UsageTotals) add(r UsageRecord) {
+	t.Calls++
+	if r.Unpriced {
+		t.UnpricedCalls++
+	}
+	t.PromptTokens += r.PromptTokens
+	t.CandidateTokens += r.CandidateTokens
+	t.TotalTokens += r.TotalTokens
+	t.CostUSD += r.CostUSD
+	t.LastSeen = r.Time
+}
+
+type UsageReport struct {
+	Since  time.Time             `json:"since"`
+	Prices map[string]ModelPrice `json:"prices"`
+	// Unpriced lists the models called without a price
+	Unpriced  []string               `json:"unpriced,omitempty"`
+	Total     UsageTotals            `json:"total"`
+	ByModel   map[string]UsageTotals `json:"byModel"`
+	ByCaller  map[string]UsageTotals `json:"byCaller"`
+	ByContext map[string]UsageTotals `json:"byContext"`
+	Recent    []UsageRecord          `json:"recent"`
+}
+
+// UsageTracker records the token usage of every GenerateContent call made
+// through the LLMs it wraps.
+type UsageTracker struct {
+	mu        sync.Mutex
+	prices    map[string]ModelPrice
+	since     time.Time
+	total     UsageTotals
+	byModel   map[string]*UsageTotals
+	byCaller  map[string]*UsageTotals
+	byContext map[string]*UsageTotals
+	recent    []UsageRecord
+	unpriced  []string
+	logger    *zerolog.Logger
+}
+
+func NewUsageTracker(prices map[string]ModelPrice, log *zerolog.Logger) *UsageTracker {
+	if prices == nil {
+		prices = DefaultPrices
+	}
+	return &UsageTracker{
+		prices:    prices,
+		since:     time.Now().UTC(),
+		byModel:   make(map[string]*UsageTotals),
+		byCaller:  make(map[string]*UsageTotals),
+		byContext: make(map[string]*UsageTotals),
+		logger:    log,
+	}
+}
+
+func (t *UsageTracker) Wrap(next LLM) LLM {
+	return &trackedLLM{next: next, tracker: t}
+}
+
+type trackedLLM struct {
+	next    LLM
+	tracker *UsageTracker
+}
+
+func (l *trackedLLM) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	resp, err := l.next.GenerateContent(ctx, model, contents, config)
+	if err == nil && resp != nil && resp.UsageMetadata != nil {
+		l.tracker.Record(ctx, model, resp.UsageMetadata)
+	}
+	return resp, err
+}
+
+func (t *UsageTracker) Record(ctx context.Context, model string, meta *genai.GenerateContentResponseUsageMetadata) UsageRecord {
+	rec := UsageRecord{
+		Time:            time.Now().UTC(),
+		Model:           model,
+		UsageScope:      usageScopeFrom(ctx),
+		PromptTokens:    int64(meta.PromptTokenCount),
+		CandidateTokens: int64(meta.CandidatesTokenCount) + int64(meta.ThoughtsTokenCount),
+		TotalTokens:     int64(meta.TotalTokenCount),
+	}
+	if rec.TotalTokens == 0 {
+		rec.TotalTokens = rec.PromptTokens + rec.CandidateTokens
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	price, ok := t.prices[model]
+	if !ok {
+		rec.Unpriced = true
+		if _, seen := t.byModel[model]; !seen {
+			t.unpriced = append(t.unpriced, model)
+			t.logger.Warn().Str("model", model).Msg("No price for model, its calls are costed at $0; set one in LLM_PRICES")
+		}
+	}
+	rec.CostUSD = (float64(rec.PromptTokens)*price.InputPerMillion + float64(rec.CandidateTokens)*price.OutputPerMillion) / 1e6
+
+	t.total.add(rec)
+	addTo(t.byModel, model, rec)
+	if rec.Caller != "" {
+		addTo(t.byCaller, rec.Caller, rec)
+	}
+	if rec.ContextID != "" {
+		if _, ok := t.byContext[rec.ContextID]; !ok && len(t.byContext) >= maxUsageContexts {
+			t.evictOldestContext()
+		}
+		addTo(t.byContext, rec.ContextID, rec)
+	}
+	t.recent = append(t.recent, rec)
+	if len(t.recent) > recentUsageRecords {
+		t.recent = t.recent[len(t.recent)-recentUsageRecords:]
+	}
+
+	usageMetrics.Add("calls."+model, 1)
+	usageMetrics.Add("prompt_tokens."+model, rec.PromptTokens)
+	usageMetrics.Add("candidate_tokens."+model, rec.CandidateTokens)
+	usageMetrics.AddFloat("cost_usd."+model, rec.CostUSD)
+	return rec
+}
+
+func addTo(m map[string]*UsageTotals, key string, rec UsageRecord) {
+	totals, ok := m[key]
+	if !ok {
+		totals = &UsageTotals{}
+		m[key] = totals
+	}
+	totals.add(rec)
+}
+
+func (t *UsageTracker) evictOldestContext() {
+	var oldest string
+	var oldestTime time.Time
+	for id, totals := range t.byContext {
+		if oldest == "" || totals.LastSeen.Before(oldestTime) {
+			oldest, oldestTime = id, totals.LastSeen
+		}
+	}
+	delete(t.byContext, oldest)
+}
+
+func (t *UsageTracker) Report() UsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return UsageReport{
+		Since:     t.since,
+		Prices:    t.prices,
+		Unpriced:  append([]string(nil), t.unpriced...),
+		Total:     t.total,
+		ByModel:   snapshot(t.byModel),
+		ByCaller:  snapshot(t.byCaller),
+		ByContext: snapshot(t.byContext),
+		Recent:    append([]UsageRecord(nil), t.recent...),
+	}
+}
+
+func snapshot(m map[string]*UsageTotals) map[string]UsageTotals {
+	out := make(map[string]UsageTotals, len(m))
+	for k, v := range m {
+		out[k] = *v
+	}
+	return out
+}
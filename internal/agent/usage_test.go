@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/genai"
+)
+
+func TestUsageTrackerFlagsUnpricedModels(t *testing.T) {
+	var logs bytes.Buffer
+	log := zerolog.New(&logs)
+	tracker := NewUsageTracker(DefaultPrices, &log)
+	ctx := WithUsageScope(context.Background(), UsageScope{Caller: "203.0.113.1", ContextID: "ctx_1"})
+	meta := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 1000, CandidatesTokenCount: 500}
+
+	priced := tracker.Record(ctx, "gemini-2.5-flash", meta)
+	if want := (1000*0.30 + 500*2.50) / 1e6; priced.Unpriced || math.Abs(priced.CostUSD-want) > 1e-12 {
+		t.Errorf("priced call: %+v, want cost %v", priced, want)
+	}
+
+	for range 2 {
+		if rec := tracker.Record(ctx, "gemini-3-flash", meta); !rec.Unpriced || rec.CostUSD != 0 {
+			t.Errorf("unpriced call: %+v", rec)
+		}
+	}
+	if n := strings.Count(logs.String(), "No price for model"); n != 1 {
+		t.Errorf("%d warnings for the unpriced model, want 1:\n%s", n, logs.String())
+	}
+
+	report := tracker.Report()
+	if len(report.Unpriced) != 1 || report.Unpriced[0] != "gemini-3-flash" {
+		t.Errorf("unpriced models %v, want [gemini-3-flash]", report.Unpriced)
+	}
+	if report.Total.Calls != 3 || report.Total.UnpricedCalls != 2 {
+		t.Errorf("total %+v, want 3 calls, 2 unpriced", report.Total)
+	}
+	if c := report.ByCaller["203.0.113.1"]; c.UnpricedCalls != 2 || c.CostUSD != priced.CostUSD {
+		t.Errorf("caller totals %+v", c)
+	}
+	if m := report.ByModel["gemini-2.5-flash"]; m.UnpricedCalls != 0 {
+		t.Errorf("priced model totals %+v", m)
+	}
+}
@@ -2,13 +2,40 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Port       string
 	JobScraper JobScraperConfig
-	// TelexAPIKey string
+	Agent      AgentConfig
+	LLM        LLMConfig
+	Semantic   SemanticConfig
+	Push       PushConfig
+	Files      FilesConfig
+	A2A        A2AConfig
+	// LLMPrices overrides per-model token prices, see agent.ParsePrices
+	LLMPrices   string
+	AdminAPIKey string
+	// AdminAllowUnauthenticated opens the admin endpoints when AdminAPIKey
+	// is empty; otherwise they are disabled
+	AdminAllowUnauthenticated bool
+	// TrustedProxies may set X-Forwarded-For; the client address of other
+	// requests is the connection's
+	TrustedProxies []netip.Prefix
+}
+
+type AgentConfig struct {
+	// Mode is "extract" for single-shot query extraction or "tools" for the
+	// tool-calling agent loop.
+	Mode     string
+	MaxSteps int
+	Timeout  time.Duration
 }
 
 type JobScraperConfig struct {
@@ -17,6 +44,73 @@ type JobScraperConfig struct {
 	RAPID_API_BASE_URL string
 }
 
+type LLMConfig struct {
+	// Models is the fallback chain, tried in order
+	Models        []string
+	LatencyBudget time.Duration
+	// RuleBasedFallback parses queries without a model when every model fails
+	RuleBasedFallback bool
+	CacheTTL          time.Duration
+	// CacheSize of 0 disables the query cache
+	CacheSize int
+	// Expansion is "off", "taxonomy" or "llm" (taxonomy plus model suggestions)
+	Expansion string
+	// TaxonomyFile replaces the embedded title taxonomy when set
+	TaxonomyFile string
+	// Rerank orders results by relevance to the request
+	Rerank bool
+	// Summaries adds a bullet summary per result; SummaryCacheSize of 0
+	// disables caching them
+	Summaries        bool
+	SummaryCacheSize int
+}
+
+type SemanticConfig struct {
+	// Embedder is "off", "hashing" (offline) or "gemini"
+	Embedder       string
+	EmbeddingModel string
+	// IndexFile persists the index across restarts when set
+	IndexFile  string
+	MaxEntries int
+}
+
+type PushConfig struct {
+	Enabled     bool
+	Workers     int
+	MaxAttempts int
+	Timeout     time.Duration
+	// DeadLetterFile collects undeliverable notifications when set. They
+	// carry whole tasks, user data included
+	DeadLetterFile string
+	// AllowPrivateURLs lets webhooks target loopback and private networks
+	AllowPrivateURLs bool
+}
+
+type FilesConfig struct {
+	// FetchURIs lets file parts point to documents by URL
+	FetchURIs        bool
+	FetchTimeout     time.Duration
+	AllowPrivateURLs bool
+}
+
+// A2AConfig describes the agent in its card.
+type A2AConfig struct {
+	// BaseURL is where clients reach the agent, e.g. https://jobs.example.com
+	BaseURL      string
+	Organization string
+	// APIKey, when set, must be sent in the X-AGENT-API-KEY header
+	APIKey string
+	// PremiumRateLimit is how many requests per minute a client address may
+	// make to each premium skill; 0 means no limit
+	PremiumRateLimit int
+	// Finished tasks are kept for TaskTTL, at most MaxFinishedTasks of them
+	TaskTTL          time.Duration
+	MaxFinishedTasks int
+	// IdleTaskTTL is how long an unfinished task is kept after it last
+	// changed
+	IdleTaskTTL time.Duration
+}
+
 func Load() (*Config, error) {
 	cfg := &Config{
 		Port: getEnv("PORT", "8080"),
@@ -25,7 +119,54 @@ func Load() (*Config, error) {
 			RAPID_API_HOST:     os.Getenv("RAPID_API_HOST"),
 			RAPID_API_BASE_URL: os.Getenv("RAPID_API_BASE_URL"),
 		},
-		// TelexAPIKey: os.Getenv("TELEX_API_KEY"),
+		Agent: AgentConfig{
+			Mode:     getEnv("AGENT_MODE", "extract"),
+			MaxSteps: getEnvInt("AGENT_MAX_STEPS", 6),
+			Timeout:  getEnvDuration("AGENT_TIMEOUT", 45*time.Second),
+		},
+		LLM: LLMConfig{
+			Models:            getEnvList("GEMINI_MODELS", []string{"gemini-2.5-flash-lite", "gemini-2.5-flash"}),
+			LatencyBudget:     getEnvDuration("LLM_LATENCY_BUDGET", 10*time.Second),
+			RuleBasedFallback: getEnvBool("RULE_BASED_FALLBACK", true),
+			CacheTTL:          getEnvDuration("QUERY_CACHE_TTL", time.Hour),
+			CacheSize:         getEnvInt("QUERY_CACHE_SIZE", 1000),
+			Expansion:         getEnv("QUERY_EXPANSION", "taxonomy"),
+			TaxonomyFile:      os.Getenv("QUERY_TAXONOMY_FILE"),
+			Rerank:            getEnvBool("RERANK_RESULTS", true),
+			Summaries:         getEnvBool("JOB_SUMMARIES", false),
+			SummaryCacheSize:  getEnvInt("SUMMARY_CACHE_SIZE", 5000),
+		},
+		Semantic: SemanticConfig{
+			Embedder:       getEnv("SEMANTIC_EMBEDDER", "hashing"),
+			EmbeddingModel: os.Getenv("EMBEDDING_MODEL"),
+			IndexFile:      os.Getenv("SEMANTIC_INDEX_FILE"),
+			MaxEntries:     getEnvInt("SEMANTIC_INDEX_MAX", 50000),
+		},
+		Push: PushConfig{
+			Enabled:          getEnvBool("PUSH_NOTIFICATIONS", true),
+			Workers:          getEnvInt("PUSH_WORKERS", 4),
+			MaxAttempts:      getEnvInt("PUSH_MAX_ATTEMPTS", 5),
+			Timeout:          getEnvDuration("PUSH_TIMEOUT", 10*time.Second),
+			DeadLetterFile:   os.Getenv("PUSH_DEAD_LETTER_FILE"),
+			AllowPrivateURLs: getEnvBool("PUSH_ALLOW_PRIVATE_URLS", false),
+		},
+		Files: FilesConfig{
+			FetchURIs:        getEnvBool("FILE_FETCH_URIS", true),
+			FetchTimeout:     getEnvDuration("FILE_FETCH_TIMEOUT", 10*time.Second),
+			AllowPrivateURLs: getEnvBool("FILE_ALLOW_PRIVATE_URLS", false),
+		},
+		LLMPrices:                 os.Getenv("LLM_PRICES"),
+		AdminAPIKey:               os.Getenv("ADMIN_API_KEY"),
+		AdminAllowUnauthenticated: getEnvBool("ADMIN_ALLOW_UNAUTHENTICATED", false),
+	}
+	cfg.A2A = A2AConfig{
+		BaseURL:          strings.TrimSuffix(getEnv("BASE_URL", "http://localhost:"+cfg.Port), "/"),
+		Organization:     getEnv("ORGANIZATION", "justinndidit.org"),
+		APIKey:           os.Getenv("TELEX_API_KEY"),
+		PremiumRateLimit: getEnvInt("PREMIUM_RATE_LIMIT", 20),
+		TaskTTL:          getEnvDuration("TASK_TTL", time.Hour),
+		MaxFinishedTasks: getEnvInt("MAX_FINISHED_TASKS", 10000),
+		IdleTaskTTL:      getEnvDuration("IDLE_TASK_TTL", time.Hour),
 	}
 
 	// Validate required fields
@@ -39,12 +180,93 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("RAPID_API_BASE_URL is required")
 	}
 
+	if cfg.Agent.Mode != "extract" && cfg.Agent.Mode != "tools" {
+		return nil, fmt.Errorf("AGENT_MODE must be \"extract\" or \"tools\", got %q", cfg.Agent.Mode)
+	}
+
+	switch cfg.LLM.Expansion {
+	case "off", "taxonomy", "llm":
+	default:
+		return nil, fmt.Errorf("QUERY_EXPANSION must be \"off\", \"taxonomy\" or \"llm\", got %q", cfg.LLM.Expansion)
+	}
+
+	if u, err := url.Parse(cfg.A2A.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("BASE_URL must be an absolute http or https url, got %q", cfg.A2A.BaseURL)
+	}
+
+	for _, proxy := range getEnvList("TRUSTED_PROXIES", nil) {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES must list addresses or CIDR ranges, got %q", proxy)
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, prefix)
+	}
+
+	if cfg.A2A.TaskTTL <= 0 {
+		return nil, fmt.Errorf("TASK_TTL must be positive, got %s", cfg.A2A.TaskTTL)
+	}
+	if cfg.A2A.IdleTaskTTL <= 0 {
+		return nil, fmt.Errorf("IDLE_TASK_TTL must be positive, got %s", cfg.A2A.IdleTaskTTL)
+	}
+
+	if cfg.A2A.PremiumRateLimit < 0 {
+		return nil, fmt.Errorf("PREMIUM_RATE_LIMIT must not be negative, got %d", cfg.A2A.PremiumRateLimit)
+	}
+
+	switch cfg.Semantic.Embedder {
+	case "off", "hashing", "gemini":
+	default:
+		return nil, fmt.Errorf("SEMANTIC_EMBEDDER must be \"off\", \"hashing\" or \"gemini\", got %q", cfg.Semantic.Embedder)
+	}
+
 	return cfg, nil
 }
 
+// parsePrefix reads a CIDR range, or a single address as a range of one.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	return netip.ParsePrefix(s)
+}
+
 func getEnv(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
 	}
 	return defaultVal
 }
+
+func getEnvInt(key string, defaultVal int) int {
+	if val, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	if val, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+func getEnvList(key string, defaultVal []string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return defaultVal
+	}
+	return list
+}
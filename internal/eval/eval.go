@@ -0,0 +1,201 @@
+package eval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// QueryProcessor is the query-extraction path under evaluation.
+type QueryProcessor interface {
+	ProcessQuery(ctx context.Context, input string) (string, error)
+}
+
+type Case struct {
+	ID       string      `json:"id" yaml:"id"`
+	Input    string      `json:"input" yaml:"input"`
+	Expected Expectation `json:"expected" yaml:"expected"`
+}
+
+type Expectation struct {
+	Invalid  bool   `json:"invalid,omitempty" yaml:"invalid,omitempty"`
+	Title    string `json:"title,omitempty" yaml:"title,omitempty"`
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+}
+
+type CaseResult struct {
+	ID       string            `json:"id"`
+	Input    string            `json:"input"`
+	Output   string            `json:"output"`
+	Error    string            `json:"error,omitempty"`
+	Invalid  bool              `json:"invalid"`
+	Fields   map[string]string `json:"fields"`
+	Passed   bool              `json:"passed"`
+	Failures []string          `json:"failures,omitempty"`
+}
+
+type FieldMetrics struct {
+	TruePositives  int     `json:"tp"`
+	FalsePositives int     `json:"fp"`
+	FalseNegatives int     `json:"fn"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+}
+
+type Report struct {
+	RunAt           time.Time               `json:"runAt"`
+	Backend         string                  `json:"backend"`
+	Model           string                  `json:"model"`
+	Total           int                     `json:"total"`
+	Passed          int                     `json:"passed"`
+	Errors          int                     `json:"errors"`
+	InvalidAccuracy float64                 `json:"invalidAccuracy"`
+	Fields          map[string]FieldMetrics `json:"fields"`
+	Cases           []CaseResult            `json:"cases"`
+}
+
+var fieldNames = []string{"title", "location"}
+
+// LoadCases reads a golden set from a .yaml/.yml file (a list of cases) or
+// from JSONL with one case per line.
+func LoadCases(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden set: %w", err)
+	}
+
+	var cases []Case
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &cases); err != nil {
+			return nil, fmt.Errorf("failed to parse golden set: %w", err)
+		}
+	default:
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			var c Case
+			if err := json.Unmarshal([]byte(text), &c); err != nil {
+				return nil, fmt.Errorf("golden set line %d: %w", line, err)
+			}
+			cases = append(cases, c)
+		}
+	}
+
+	for i := range cases {
+		if cases[i].ID == "" {
+			cases[i].ID = fmt.Sprintf("case-%03d", i+1)
+		}
+	}
+	return cases, nil
+}
+
+func Run(ctx context.Context, p QueryProcessor, cases []Case) *Report {
+	report := &Report{
+		RunAt:  time.Now().UTC(),
+		Total:  len(cases),
+		Fields: make(map[string]FieldMetrics),
+	}
+
+	invalidCorrect := 0
+	for _, c := range cases {
+		res := CaseResult{ID: c.ID, Input: c.Input, Fields: map[string]string{}}
+
+		out, err := p.ProcessQuery(ctx, c.Input)
+		if err != nil {
+			res.Error = err.Error()
+			res.Failures = append(res.Failures, "error")
+			report.Errors++
+			report.Cases = append(report.Cases, res)
+			for _, f := range fieldNames {
+				if expectedField(c.Expected, f) != "" {
+					m := report.Fields[f]
+					m.FalseNegatives++
+					report.Fields[f] = m
+				}
+			}
+			continue
+		}
+
+		res.Output = out
+		res.Invalid = strings.TrimSpace(out) == "invalid"
+		if res.Invalid == c.Expected.Invalid {
+			invalidCorrect++
+		} else {
+			res.Failures = append(res.Failures, "invalid")
+		}
+
+		query := util.ParseMessage(out)
+		if res.Invalid {
+			query.Title, query.Location = "", ""
+		}
+		res.Fields["title"] = query.Title
+		res.Fields["location"] = query.Location
+
+		for _, f := range fieldNames {
+			want, got := normalize(expectedField(c.Expected, f)), normalize(res.Fields[f])
+			m := report.Fields[f]
+			switch {
+			case got != "" && got == want:
+				m.TruePositives++
+			case got != "" && want != "":
+				m.FalsePositives++
+				m.FalseNegatives++
+				res.Failures = append(res.Failures, f)
+			case got != "":
+				m.FalsePositives++
+				res.Failures = append(res.Failures, f)
+			case want != "":
+				m.FalseNegatives++
+				res.Failures = append(res.Failures, f)
+			}
+			report.Fields[f] = m
+		}
+
+		res.Passed = len(res.Failures) == 0
+		if res.Passed {
+			report.Passed++
+		}
+		report.Cases = append(report.Cases, res)
+	}
+
+	for f, m := range report.Fields {
+		m.Precision = ratio(m.TruePositives, m.TruePositives+m.FalsePositives)
+		m.Recall = ratio(m.TruePositives, m.TruePositives+m.FalseNegatives)
+		report.Fields[f] = m
+	}
+	report.InvalidAccuracy = ratio(invalidCorrect, len(cases)-report.Errors)
+	return report
+}
+
+func expectedField(e Expectation, field string) string {
+	switch field {
+	case "title":
+		return e.Title
+	case "location":
+		return e.Location
+	}
+	return ""
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.Trim(s, " .\"'"))), " ")
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
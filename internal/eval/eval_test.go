@@ -0,0 +1,191 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/rs/zerolog"
+)
+
+// answers is a QueryProcessor returning canned outputs by input.
+type answers map[string]string
+
+func (a answers) ProcessQuery(ctx context.Context, input string) (string, error) {
+	out, ok := a[input]
+	if !ok {
+		return "", errors.New("backend down")
+	}
+	return out, nil
+}
+
+func TestRunScoresFields(t *testing.T) {
+	cases := []Case{
+		{ID: "exact", Input: "go jobs in Berlin", Expected: Expectation{Title: "Go developer", Location: "Berlin"}},
+		{ID: "wrong-location", Input: "nurse in Lagos", Expected: Expectation{Title: "nurse", Location: "Lagos"}},
+		{ID: "extra-location", Input: "product manager", Expected: Expectation{Title: "product manager"}},
+		{ID: "missed-title", Input: "designer in Paris", Expected: Expectation{Title: "designer", Location: "Paris"}},
+		{ID: "invalid", Input: "hello", Expected: Expectation{Invalid: true}},
+		{ID: "missed-invalid", Input: "weather?", Expected: Expectation{Invalid: true}},
+		{ID: "error", Input: "teacher in Nairobi", Expected: Expectation{Title: "teacher", Location: "Nairobi"}},
+	}
+	p := answers{
+		"go jobs in Berlin": "title: go  developer, location: berlin.",
+		"nurse in Lagos":    "title: nurse, location: Abuja",
+		"product manager":   "title: product manager, location: London",
+		"designer in Paris": "location: Paris",
+		"hello":             "invalid",
+		"weather?":          "title: weather",
+	}
+
+	r := Run(context.Background(), p, cases)
+	if r.Total != 7 || r.Passed != 2 || r.Errors != 1 {
+		t.Errorf("total %d, passed %d, errors %d; want 7, 2, 1", r.Total, r.Passed, r.Errors)
+	}
+	// 5 of the 6 answered cases got invalid right
+	if want := 5.0 / 6; r.InvalidAccuracy != want {
+		t.Errorf("invalid accuracy %.3f, want %.3f", r.InvalidAccuracy, want)
+	}
+
+	want := map[string]FieldMetrics{
+		// tp: exact, wrong-location, extra-location; fp: weather; fn: missed-title, error
+		"title": {TruePositives: 3, FalsePositives: 1, FalseNegatives: 2, Precision: 3.0 / 4, Recall: 3.0 / 5},
+		// tp: exact, missed-title; fp: wrong-location, extra-location; fn: wrong-location, error
+		"location": {TruePositives: 2, FalsePositives: 2, FalseNegatives: 2, Precision: 2.0 / 4, Recall: 2.0 / 4},
+	}
+	for f, m := range want {
+		if r.Fields[f] != m {
+			t.Errorf("%s: %+v, want %+v", f, r.Fields[f], m)
+		}
+	}
+
+	failures := map[string]string{
+		"exact":          "",
+		"wrong-location": "location",
+		"extra-location": "location",
+		"missed-title":   "title",
+		"invalid":        "",
+		"missed-invalid": "invalid,title",
+		"error":          "error",
+	}
+	for _, c := range r.Cases {
+		if got := strings.Join(c.Failures, ","); got != failures[c.ID] || c.Passed != (got == "") {
+			t.Errorf("%s: failures %q (passed %v), want %q", c.ID, got, c.Passed, failures[c.ID])
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	prev := &Report{
+		InvalidAccuracy: 1,
+		Fields:          map[string]FieldMetrics{"title": {Precision: 1, Recall: 0.5}},
+		Cases: []CaseResult{
+			{ID: "a", Output: "title: nurse", Passed: true},
+			{ID: "b", Output: "title: teachr", Passed: false},
+			{ID: "c", Output: "title: chef", Passed: true},
+			{ID: "d", Output: "invalid", Passed: true},
+		},
+	}
+	cur := &Report{
+		InvalidAccuracy: 1,
+		Fields:          map[string]FieldMetrics{"title": {Precision: 1, Recall: 0.75}},
+		Cases: []CaseResult{
+			{ID: "a", Output: "title: doctor", Passed: false},
+			{ID: "b", Output: "title: teacher", Passed: true},
+			{ID: "c", Output: "title: chef, location: Rome", Passed: true},
+			{ID: "d", Output: "invalid", Passed: true},
+			{ID: "e", Output: "invalid", Passed: true},
+		},
+	}
+
+	got := Diff(prev, cur)
+	want := []string{
+		"title recall 0.500 → 0.750 (+0.250)",
+		`REGRESSION a: "title: nurse" → "title: doctor"`,
+		`changed c: "title: chef" → "title: chef, location: Rome"`,
+		`fixed b: "title: teachr" → "title: teacher"`,
+		"new case e",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Diff:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if lines := Diff(nil, cur); lines != nil {
+		t.Errorf("Diff without a previous run = %q", lines)
+	}
+	if lines := Diff(cur, cur); len(lines) != 0 {
+		t.Errorf("Diff of a run with itself = %q", lines)
+	}
+}
+
+func TestReportRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last_run.json")
+	if r, err := LoadReport(path); r != nil || err != nil {
+		t.Fatalf("missing report: %v, %v; want nothing", r, err)
+	}
+
+	r := Run(context.Background(), answers{"hello": "invalid"}, []Case{{Input: "hello", Expected: Expectation{Invalid: true}}})
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := Diff(loaded, r); len(lines) != 0 {
+		t.Errorf("saved report differs: %q", lines)
+	}
+}
+
+func TestLoadCases(t *testing.T) {
+	dir := t.TempDir()
+	jsonl := filepath.Join(dir, "golden.jsonl")
+	yaml := filepath.Join(dir, "golden.yaml")
+	os.WriteFile(jsonl, []byte("# comment\n{\"id\": \"a\", \"input\": \"nurse in Lagos\", \"expected\": {\"title\": \"nurse\"}}\n\n{\"input\": \"hello\", \"expected\": {\"invalid\": true}}\n"), 0o644)
+	os.WriteFile(yaml, []byte("- id: a\n  input: nurse in Lagos\n  expected: {title: nurse}\n- input: hello\n  expected: {invalid: true}\n"), 0o644)
+
+	for _, path := range []string{jsonl, yaml} {
+		cases, err := LoadCases(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(cases) != 2 || cases[0].ID != "a" || cases[0].Expected.Title != "nurse" || cases[1].ID != "case-002" || !cases[1].Expected.Invalid {
+			t.Errorf("%s: %+v", path, cases)
+		}
+	}
+
+	os.WriteFile(jsonl, []byte("{\"id\": \"a\"}\n{broken\n"), 0o644)
+	if _, err := LoadCases(jsonl); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("broken line: %v", err)
+	}
+}
+
+// TestReplayGoldenSet runs the golden set offline against the committed
+// recordings, through the same agent path as a live run.
+func TestReplayGoldenSet(t *testing.T) {
+	cases, err := LoadCases("../../eval/golden.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm, err := agent.LoadReplayLLM("../../eval/recordings.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zerolog.Nop()
+	r := Run(context.Background(), agent.NewGeminiAgentWithLLM(llm, agent.DefaultModel, &log), cases)
+
+	for _, c := range r.Cases {
+		if c.Error != "" {
+			t.Errorf("%s: %s", c.ID, c.Error)
+		}
+	}
+	if r.Errors > 0 {
+		t.Fatal("recordings do not match the current prompt; record them again: rm eval/recordings.jsonl && go run ./cmd/evalprompt -backend gemini -record eval/recordings.jsonl")
+	}
+	if lines := Diff(r, Run(context.Background(), agent.NewGeminiAgentWithLLM(llm, agent.DefaultModel, &log), cases)); len(lines) != 0 {
+		t.Errorf("second replay differs: %q", lines)
+	}
+}
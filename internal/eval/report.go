@@ -0,0 +1,106 @@
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LoadReport reads a previous run. A missing file is not an error; it simply
+// means there is nothing to diff against.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &r, nil
+}
+
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Backend: %s  Model: %s\n", r.Backend, r.Model)
+	fmt.Fprintf(w, "Cases: %d  Passed: %d  Errors: %d\n", r.Total, r.Passed, r.Errors)
+	fmt.Fprintf(w, "Invalid detection accuracy: %.3f\n", r.InvalidAccuracy)
+	for _, f := range fieldNames {
+		m := r.Fields[f]
+		fmt.Fprintf(w, "  %-9s precision %.3f  recall %.3f  (tp %d, fp %d, fn %d)\n",
+			f, m.Precision, m.Recall, m.TruePositives, m.FalsePositives, m.FalseNegatives)
+	}
+
+	for _, c := range r.Cases {
+		if c.Passed {
+			continue
+		}
+		detail := c.Output
+		if c.Error != "" {
+			detail = "error: " + c.Error
+		}
+		fmt.Fprintf(w, "FAIL %s [%s] %q → %q\n", c.ID, strings.Join(c.Failures, ","), c.Input, detail)
+	}
+}
+
+// Diff describes how cur changed relative to prev: metric deltas, cases that
+// flipped between pass and fail, and cases whose raw output changed.
+func Diff(prev, cur *Report) []string {
+	if prev == nil {
+		return nil
+	}
+
+	var lines []string
+	if d := cur.InvalidAccuracy - prev.InvalidAccuracy; d != 0 {
+		lines = append(lines, fmt.Sprintf("invalid accuracy %.3f → %.3f (%+.3f)", prev.InvalidAccuracy, cur.InvalidAccuracy, d))
+	}
+	for _, f := range fieldNames {
+		p, c := prev.Fields[f], cur.Fields[f]
+		if p.Precision != c.Precision {
+			lines = append(lines, fmt.Sprintf("%s precision %.3f → %.3f (%+.3f)", f, p.Precision, c.Precision, c.Precision-p.Precision))
+		}
+		if p.Recall != c.Recall {
+			lines = append(lines, fmt.Sprintf("%s recall %.3f → %.3f (%+.3f)", f, p.Recall, c.Recall, c.Recall-p.Recall))
+		}
+	}
+
+	previous := make(map[string]CaseResult, len(prev.Cases))
+	for _, c := range prev.Cases {
+		previous[c.ID] = c
+	}
+
+	var caseLines []string
+	for _, c := range cur.Cases {
+		p, ok := previous[c.ID]
+		switch {
+		case !ok:
+			caseLines = append(caseLines, fmt.Sprintf("new case %s", c.ID))
+		case p.Passed && !c.Passed:
+			caseLines = append(caseLines, fmt.Sprintf("REGRESSION %s: %q → %q", c.ID, p.Output, c.Output))
+		case !p.Passed && c.Passed:
+			caseLines = append(caseLines, fmt.Sprintf("fixed %s: %q → %q", c.ID, p.Output, c.Output))
+		case p.Output != c.Output:
+			caseLines = append(caseLines, fmt.Sprintf("changed %s: %q → %q", c.ID, p.Output, c.Output))
+		}
+	}
+	sort.Strings(caseLines)
+	return append(lines, caseLines...)
+}
@@ -0,0 +1,173 @@
+// Package expand widens a job title into the synonyms and related titles
+// postings are likely to use, e.g. "golang dev" → "go developer", "backend
+// engineer".
+package expand
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	MaxTitles = 5
+
+	suggestionTTL   = 24 * time.Hour
+	maxSuggestCache = 1000
+)
+
+//go:embed taxonomy.yaml
+var defaultTaxonomy []byte
+
+// Entry is one group in the taxonomy.
+type Entry struct {
+	Title   string   `yaml:"title"`
+	Aliases []string `yaml:"aliases"`
+	Related []string `yaml:"related"`
+}
+
+type Taxonomy []Entry
+
+// LoadTaxonomy reads a taxonomy file, or the embedded one when path is empty.
+func LoadTaxonomy(path string) (Taxonomy, error) {
+	data := defaultTaxonomy
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("read taxonomy: %w", err)
+		}
+	}
+	var tax Taxonomy
+	if err := yaml.Unmarshal(data, &tax); err != nil {
+		return nil, fmt.Errorf("parse taxonomy: %w", err)
+	}
+	for i, e := range tax {
+		if strings.TrimSpace(e.Title) == "" {
+			return nil, fmt.Errorf("taxonomy entry %d has no title", i+1)
+		}
+	}
+	return tax, nil
+}
+
+// Suggester proposes alternative titles, typically with an LLM.
+type Suggester interface {
+	SuggestTitles(ctx context.Context, title string) ([]string, error)
+}
+
+type suggestion struct {
+	titles  []string
+	expires time.Time
+}
+
+// Expander maps a title onto the titles to search for: the title itself, its
+// canonical form, then related titles and any LLM suggestions.
+type Expander struct {
+	entries   []compiledEntry
+	suggester Suggester
+	mu        sync.Mutex
+	suggested map[string]suggestion
+	logger    *zerolog.Logger
+}
+
+type compiledEntry struct {
+	Entry
+	pattern *regexp.Regexp
+}
+
+func NewExpander(tax Taxonomy, log *zerolog.Logger) *Expander {
+	entries := make([]compiledEntry, 0, len(tax))
+	for _, e := range tax {
+		names := append([]string{e.Title}, e.Aliases...)
+		quoted := make([]string, 0, len(names))
+		for _, n := range names {
+			quoted = append(quoted, regexp.QuoteMeta(strings.ToLower(strings.TrimSpace(n))))
+		}
+		pattern := regexp.MustCompile(`(^|[^\pL\pN])(?:` + strings.Join(quoted, "|") + `)($|[^\pL\pN])`)
+		entries = append(entries, compiledEntry{Entry: e, pattern: pattern})
+	}
+	return &Expander{entries: entries, suggested: make(map[string]suggestion), logger: log}
+}
+
+// UseSuggester adds LLM suggestions after the taxonomy's titles.
+func (x *Expander) UseSuggester(s Suggester) {
+	x.suggester = s
+}
+
+// Expand returns up to MaxTitles distinct titles, title first. A failing
+// suggester is logged and ignored.
+func (x *Expander) Expand(ctx context.Context, title string) []string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil
+	}
+	lower := strings.ToLower(title)
+
+	// Qualifiers around the match are kept: "senior swe" → "senior software engineer"
+	titles := []string{title}
+	var related []string
+	for _, e := range x.entries {
+		if e.pattern.MatchString(lower) {
+			titles = append(titles, e.substitute(lower, e.Title))
+			for _, r := range e.Related {
+				related = append(related, e.substitute(lower, r))
+			}
+		}
+	}
+	titles = append(titles, related...)
+	if x.suggester != nil {
+		titles = append(titles, x.suggest(ctx, lower)...)
+	}
+	return dedupe(titles, MaxTitles)
+}
+
+func (x *Expander) suggest(ctx context.Context, title string) []string {
+	x.mu.Lock()
+	cached, ok := x.suggested[title]
+	x.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.titles
+	}
+
+	titles, err := x.suggester.SuggestTitles(ctx, title)
+	if err != nil {
+		x.logger.Warn().Err(err).Str("title", title).Msg("Title suggestions failed")
+		return nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.suggested) >= maxSuggestCache {
+		x.suggested = make(map[string]suggestion)
+	}
+	x.suggested[title] = suggestion{titles: titles, expires: time.Now().Add(suggestionTTL)}
+	return titles
+}
+
+func (e compiledEntry) substitute(title, replacement string) string {
+	return strings.TrimSpace(e.pattern.ReplaceAllString(title, "${1}"+replacement+"${2}"))
+}
+
+func dedupe(titles []string, n int) []string {
+	seen := make(map[string]bool, len(titles))
+	out := make([]string, 0, n)
+	for _, t := range titles {
+		key := strings.ToLower(strings.TrimSpace(t))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, strings.TrimSpace(t))
+		if len(out) == n {
+			break
+		}
+	}
+	return out
+}
@@ -1,28 +1,66 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/justinndidit/job-agent/internal/i18n"
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/justinndidit/job-agent/internal/push"
 	"github.com/justinndidit/job-agent/internal/scraper"
 	"github.com/rs/zerolog"
 )
 
 type A2AHandler struct {
-	executor    *agent.AgentExecutor
+	executor *agent.AgentExecutor
+	tasks    *a2a.TaskStore
+	running  runningTasks
+	events   taskEvents
+	push     *push.Notifier
+	// files downloads file part URIs; nil keeps files inline only
+	files             *http.Client
+	filesAllowPrivate bool
+	// timeout bounds each request but streams
+	timeout     time.Duration
+	cfg         config.A2AConfig
+	skills      []a2a.AgentSkill
+	premium     []a2a.AgentSkill
+	limits      skillLimits
 	logger      *zerolog.Logger
 	telexAPIKey string
 }
 
-func NewA2AHandler(executor *agent.AgentExecutor, logger *zerolog.Logger) *A2AHandler {
-	return &A2AHandler{
-		executor: executor,
-		logger:   logger,
-		// telexAPIKey: apiKey,
+func NewA2AHandler(executor *agent.AgentExecutor, cfg config.A2AConfig, logger *zerolog.Logger) *A2AHandler {
+	h := &A2AHandler{
+		executor:    executor,
+		tasks:       a2a.NewTaskStore(),
+		running:     runningTasks{cancels: make(map[string]context.CancelFunc)},
+		events:      taskEvents{subs: make(map[string]map[chan interface{}]struct{})},
+		timeout:     RequestTimeout,
+		cfg:         cfg,
+		logger:      logger,
+		telexAPIKey: cfg.APIKey,
 	}
+	h.tasks.Retain(cfg.TaskTTL, cfg.MaxFinishedTasks)
+	h.tasks.RetainIdle(cfg.IdleTaskTTL)
+	h.tasks.OnEvict(h.forgetTask)
+	for _, skill := range baseSkills {
+		h.RegisterSkill(skill)
+	}
+	for _, skill := range premiumSkills {
+		h.RegisterPremiumSkill(skill, cfg.PremiumRateLimit)
+	}
+	return h
 }
 
 // A2A Protocol Types (JSON-RPC 2.0 with proper A2A structures)
@@ -31,10 +69,20 @@ type A2ARequest struct {
 	Method  string      `json:"method"`
 	Params  A2AParams   `json:"params"`
 	ID      interface{} `json:"id"`
+	// notification is set for requests without an id, which get no response
+	notification bool
 }
 
 type A2AParams struct {
-	Message Message `json:"message"`
+	Message       Message                   `json:"message"`
+	Configuration *a2a.MessageConfiguration `json:"configuration,omitempty"`
+	// ID and HistoryLength are the tasks/get and tasks/cancel parameters
+	ID            string `json:"id,omitempty"`
+	HistoryLength *int   `json:"historyLength,omitempty"`
+	// tasks/pushNotificationConfig/* parameters
+	TaskID                   string                      `json:"taskId,omitempty"`
+	PushNotificationConfig   *a2a.PushNotificationConfig `json:"pushNotificationConfig,omitempty"`
+	PushNotificationConfigID string                      `json:"pushNotificationConfigId,omitempty"`
 }
 
 type Message struct {
@@ -50,13 +98,24 @@ type Message struct {
 }
 
 type Part struct {
-	Kind string `json:"kind"` // "text", "file", or "data"
-	Text string `json:"text,omitempty"`
+	Kind     string          `json:"kind"` // "text", "file", or "data"
+	Text     string          `json:"text,omitempty"`
+	File     *FilePart       `json:"file,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Metadata map[string]any  `json:"metadata,omitempty"`
+}
+
+// FilePart carries an attached file, inline as base64 bytes or by URI.
+type FilePart struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"`
+	URI      string `json:"uri,omitempty"`
 }
 
 type A2AResponse struct {
 	JSONRPC string      `json:"jsonrpc"`
-	Result  *Message    `json:"result,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
 	Error   *A2AError   `json:"error,omitempty"`
 	ID      interface{} `json:"id"`
 }
@@ -67,147 +126,348 @@ type A2AError struct {
 	Data    any    `json:"data,omitempty"`
 }
 
-// AgentCard returns the A2A agent card (GET /.well-known/agent.json)
-func (h *A2AHandler) AgentCard(w http.ResponseWriter, r *http.Request) {
-	card := map[string]interface{}{
-		"name":        "Job Search Agent",
-		"description": "AI-powered job search agent that finds relevant job postings using natural language queries",
-		"url":         "https://involved-sheree-surgee-fcce11ee.koyeb.app", // Replace with your actual base URL
-		"version":     "1.0.0",
-		"provider": map[string]string{
-			"organization": "justinndidit.org",
-			"url":          "https://involved-sheree-surgee-fcce11ee.koyeb.app",
-		},
-		"capabilities": map[string]bool{
-			"streaming":              false,
-			"pushNotifications":      false,
-			"stateTransitionHistory": false,
-		},
-		"defaultInputModes":  []string{"text/plain"},
-		"defaultOutputModes": []string{"text/plain", "application/json"},
-		"skills": []map[string]interface{}{
-			{
-				"id":          "job_search",
-				"name":        "Job Search",
-				"description": "Search for jobs by title and location using natural language",
-				"inputModes":  []string{"text/plain"},
-				"outputModes": []string{"text/plain"},
-				"examples": []map[string]interface{}{
-					{
-						"input": map[string]interface{}{
-							"parts": []map[string]string{
-								{"text": "Find software engineer jobs in New York", "contentType": "text/plain"},
-							},
-						},
-						"output": map[string]interface{}{
-							"parts": []map[string]string{
-								{"text": "Found 5 job opportunities...", "contentType": "text/plain"},
-							},
-						},
-					},
-				},
-			},
-		},
-		"supportsAuthenticatedExtendedCard": false,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(card)
-}
-
 // HandleA2A processes all A2A protocol requests (POST /)
 func (h *A2AHandler) HandleA2A(w http.ResponseWriter, r *http.Request) {
-	// Authenticate
-	// apiKey := r.Header.Get("X-AGENT-API-KEY")
-	// if apiKey == "" {
-	// 	h.logger.Warn().Msg("Missing API key")
-	// 	h.sendError(w, nil, -32600, "Missing X-AGENT-API-KEY header")
-	// 	return
-	// }
-
-	// if h.telexAPIKey != "" && apiKey != h.telexAPIKey {
-	// 	h.logger.Warn().Str("key", maskKey(apiKey)).Msg("Invalid API key")
-	// 	h.sendError(w, nil, -32600, "Invalid API key")
-	// 	return
-	// }
-
-	// Parse request
-	var req A2ARequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error().Err(err).Msg("Failed to decode request")
+	if !h.authenticated(w, r) {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("Failed to read request")
+		h.sendError(w, nil, -32600, "Invalid Request: body unreadable or over the size limit")
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
 		h.sendError(w, nil, -32700, "Parse error")
 		return
 	}
 
-	h.logger.Info().
-		Str("method", req.Method).
-		Str("role", req.Params.Message.Role).
-		Msg("A2A request received")
+	if body[0] == '[' {
+		h.handleBatch(w, r, body)
+		return
+	}
+	if !h.dispatch(w, r, body, false) {
+		// Notifications get no response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// route calls the handler of the request's method, within h.timeout unless
+// it streams.
+func (h *A2AHandler) route(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	if req.Method != "message/stream" && req.Method != "tasks/resubscribe" {
+		ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
 
-	// Route based on method
 	switch req.Method {
 	case "message/send":
-		h.handleMessageSend(w, r, &req)
-	case "task/subscribe":
-		h.sendError(w, req.ID, -32601, "Method not supported: task/subscribe")
+		h.handleMessageSend(w, r, req)
+	case "tasks/get":
+		h.handleTaskGet(w, r, req)
+	case "tasks/cancel":
+		h.handleTaskCancel(w, r, req)
+	case "tasks/pushNotificationConfig/set":
+		h.handlePushConfigSet(w, r, req)
+	case "tasks/pushNotificationConfig/get":
+		h.handlePushConfigGet(w, r, req)
+	case "tasks/pushNotificationConfig/list":
+		h.handlePushConfigList(w, r, req)
+	case "tasks/pushNotificationConfig/delete":
+		h.handlePushConfigDelete(w, r, req)
+	case "message/stream":
+		h.handleMessageStream(w, r, req)
+	case "tasks/resubscribe":
+		h.handleTaskResubscribe(w, r, req)
+	case "agent/getAuthenticatedExtendedCard":
+		h.handleExtendedCard(w, req)
 	default:
 		h.sendError(w, req.ID, -32601, "Method not found: "+req.Method)
 	}
 }
 
-func (h *A2AHandler) handleMessageSend(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
-	// Extract text from parts
-	var userQuery string
-	for _, part := range req.Params.Message.Parts {
-		if part.Kind == "text" && part.Text != "" {
-			userQuery = part.Text
-			break
+// messageInput is what a message/send request asks for.
+type messageInput struct {
+	text   string
+	resume string
+	// jobDescription is an attached job posting to find similar roles to
+	jobDescription string
+	// query is a structured search from a data part, run without the model
+	query *scraper.JobQuery
+	// outputModes are the MIME types the client accepts; empty means any
+	outputModes []string
+}
+
+// parseInput reads the text, an attached document and a structured query
+// from the message parts, and checks that the client accepts what the
+// requested skill returns.
+func (h *A2AHandler) parseInput(ctx context.Context, params A2AParams) (messageInput, *A2AError) {
+	var input messageInput
+	var file *Part
+	for i, part := range params.Message.Parts {
+		switch {
+		case part.Kind == "text" && part.Text != "" && input.text == "":
+			input.text = part.Text
+		case part.Kind == "file" && file == nil:
+			file = &params.Message.Parts[i]
+		case part.Kind == "data" && len(part.Data) > 0 && input.query == nil:
+			query, err := parseQueryData(part.Data)
+			if err != nil {
+				return input, &A2AError{Code: -32602, Message: "Invalid data part: " + err.Error()}
+			}
+			input.query = &query
 		}
 	}
+	if file != nil {
+		if file.File == nil {
+			return input, &A2AError{Code: -32602, Message: "File part without a file"}
+		}
+		text, rpcErr := h.fileText(ctx, file.File)
+		if rpcErr != nil {
+			return input, rpcErr
+		}
+		if fileRole(*file, input.text) == roleJobDescription {
+			input.jobDescription = text
+		} else {
+			input.resume = text
+		}
+	}
+	if input.text == "" && input.resume == "" && input.jobDescription == "" && input.query == nil {
+		return input, &A2AError{Code: -32602, Message: "No text content in message"}
+	}
 
-	if userQuery == "" {
-		h.sendError(w, req.ID, -32602, "No text content in message")
+	if params.Configuration != nil {
+		input.outputModes = params.Configuration.AcceptedOutputModes
+	}
+	produced := []string{"text/plain", "application/json"}
+	if _, ok := agent.ParseCoverLetterRequest(input.text); ok {
+		produced = []string{"text/markdown", "text/plain"}
+	}
+	if !acceptsAny(input.outputModes, produced...) {
+		return input, &A2AError{Code: errContentTypeNotSupported, Message: "Incompatible content types: this skill returns " + strings.Join(produced, " or ")}
+	}
+	return input, nil
+}
+
+func (h *A2AHandler) handleMessageSend(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	input, rpcErr := h.parseInput(r.Context(), req.Params)
+	if rpcErr != nil {
+		h.sendError(w, req.ID, rpcErr.Code, rpcErr.Message)
 		return
 	}
 
-	// Execute search
-	jobs, err := h.executor.SearchJobTool(r.Context(), userQuery)
-	if err != nil {
-		h.logger.Error().Err(err).Msg("Search failed")
-		h.sendError(w, req.ID, -32603, "Search failed: "+err.Error())
+	if !h.checkPushConfig(w, req.ID, req.Params.Configuration) || !h.checkRateLimit(w, r, req.ID, input) {
 		return
 	}
 
-	// Format response as A2A Message
-	responseText := h.formatJobs(jobs)
+	task, pending, rpcErr := h.beginTask(r, req)
+	if rpcErr != nil {
+		h.sendError(w, req.ID, rpcErr.Code, rpcErr.Message)
+		return
+	}
+	h.registerPushConfig(task.ID, req.Params.Configuration)
+	if config := req.Params.Configuration; config != nil && config.Blocking != nil && !*config.Blocking {
+		// Non-blocking: reply with the submitted task, the client follows up
+		// with tasks/get or push notifications
+		go h.runTask(context.WithoutCancel(usageContext(r, task.ContextID)), req, task, input, pending)
+		h.sendResult(w, req.ID, task)
+		return
+	}
+	result := h.runTask(usageContext(r, task.ContextID), req, task, input, pending)
+	h.sendResult(w, req.ID, result)
+}
 
-	responseMessage := Message{
-		Role:      "agent",
-		Parts:     []Part{{Kind: "text", Text: responseText}},
-		MessageID: generateMessageID(),
-		Kind:      "message",
-		Metadata: map[string]any{
-			"jobCount":  len(jobs),
-			"timestamp": time.Now().Unix(),
-		},
+// runTask works on a task until it finishes, reporting progress to its
+// subscribers, and returns the finished task.
+func (h *A2AHandler) runTask(parent context.Context, req *A2ARequest, task *a2a.TaskResult, input messageInput, pending scraper.JobQuery) *a2a.TaskResult {
+	ctx, done := h.running.start(parent, task.ID)
+	defer done()
+	h.setTaskState(task.ID, TaskStateWorking, nil, nil)
+	ctx = agent.WithProgress(ctx, h.progress(task, i18n.Detect(input.text), input.outputModes))
+	return h.finishTask(task.ID, h.execute(ctx, req, input, pending))
+}
+
+func (h *A2AHandler) sendResult(w http.ResponseWriter, id interface{}, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(A2AResponse{JSONRPC: "2.0", Result: result, ID: id})
+}
+
+// taskOutcome is how a request ended: the task's final state, the agent's
+// reply and any artifacts it produced.
+type taskOutcome struct {
+	state     string
+	reply     Message
+	artifacts []a2a.Artifact
+	metadata  map[string]interface{}
+	// pending is the query an input-required task continues with
+	pending *scraper.JobQuery
+}
+
+// execute runs the skill the message asks for. Failures are reported
+// through the outcome's state rather than as JSON-RPC errors.
+func (h *A2AHandler) execute(ctx context.Context, req *A2ARequest, input messageInput, pending scraper.JobQuery) taskOutcome {
+	if letterReq, ok := agent.ParseCoverLetterRequest(input.text); ok {
+		return h.coverLetter(ctx, req, letterReq, input)
 	}
 
-	// If the incoming message had a taskId or contextId, include them
-	if req.Params.Message.TaskID != "" {
-		responseMessage.TaskID = req.Params.Message.TaskID
+	var result *agent.SearchResult
+	var err error
+	contextID := req.Params.Message.ContextID
+	switch {
+	case input.query != nil:
+		result, err = h.executor.SearchQuery(ctx, contextID, *input.query)
+	case input.jobDescription != "":
+		result, err = h.executor.SimilarRoles(ctx, contextID, input.jobDescription, input.text)
+	case input.resume != "":
+		result, err = h.executor.MatchResume(ctx, contextID, input.resume, input.text)
+	default:
+		result, err = h.executor.Search(ctx, contextID, pending, input.text)
 	}
-	if req.Params.Message.ContextID != "" {
-		responseMessage.ContextID = req.Params.Message.ContextID
+	lang := i18n.Detect(input.text)
+
+	var clarify *agent.ClarificationError
+	switch {
+	case errors.As(err, &clarify):
+		h.logger.Info().Str("task_id", req.Params.Message.TaskID).Msg("Asking for clarification")
+		return taskOutcome{
+			state:   TaskStateInputRequired,
+			reply:   agentMessage(clarify.Question, map[string]any{"language": i18n.Normalize(clarify.Pending.Language)}),
+			pending: &clarify.Pending,
+		}
+	case isRejectedInput(err):
+		return taskOutcome{
+			state: TaskStateRejected,
+			reply: agentMessage("Message rejected: "+err.Error(), map[string]any{"language": i18n.Normalize(lang)}),
+		}
+	case err != nil && ctx.Err() != nil:
+		h.logger.Info().Err(err).Msg("Search canceled")
+		return taskOutcome{state: TaskStateCanceled, reply: agentMessage("Task canceled.", nil)}
+	case err != nil:
+		h.logger.Error().Err(err).Msg("Search failed")
+		return failedOutcome(lang)
 	}
 
-	response := A2AResponse{
-		JSONRPC: "2.0",
-		Result:  &responseMessage,
-		ID:      req.ID,
+	reply := h.searchReply(result)
+	return taskOutcome{
+		state:     TaskStateCompleted,
+		reply:     reply,
+		artifacts: []a2a.Artifact{jobResultsArtifact(reply.Parts[0].Text, result, input.outputModes)},
 	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// searchReply formats a search result as the agent's message.
+func (h *A2AHandler) searchReply(result *agent.SearchResult) Message {
+	jobs := result.Jobs
+	lang := i18n.Normalize(result.Query.Language)
+	responseText := h.formatJobs(jobs, lang)
+	if result.Answer != "" {
+		responseText = result.Answer
+	} else if result.Refined {
+		responseText = i18n.T(lang, "refined_search", describeQuery(result.Query, lang)) + "\n\n" + responseText
+	} else if result.SimilarTo != "" {
+		responseText = i18n.T(lang, "similar_roles", result.SimilarTo) + "\n\n" + responseText
+	} else if result.Profile != nil {
+		responseText = i18n.T(lang, "resume_profile", strings.Join(result.Profile.Titles, ", ")) + "\n\n" + responseText
+	}
+	if result.Similar > 0 && result.Answer == "" {
+		responseText += "\n" + i18n.T(lang, "similar_jobs", result.Similar)
+	}
+	if len(result.Query.Titles) > 1 && result.Answer == "" {
+		responseText = i18n.T(lang, "searched_titles", strings.Join(result.Query.Titles, ", ")) + "\n" + responseText
+	}
+
+	reply := agentMessage(responseText, map[string]any{
+		"jobCount":  len(jobs),
+		"timestamp": time.Now().Unix(),
+		"language":  lang,
+	})
+	if len(result.Query.Titles) > 0 {
+		reply.Metadata["searchedTitles"] = result.Query.Titles
+	}
+	return reply
+}
+
+// coverLetter drafts a cover letter for a job from the context's last
+// results and returns it as a markdown artifact. Tone and length come from
+// the message text or its "tone"/"length" metadata.
+func (h *A2AHandler) coverLetter(ctx context.Context, req *A2ARequest, letterReq agent.CoverLetterRequest, input messageInput) taskOutcome {
+	if tone, ok := req.Params.Message.Metadata["tone"].(string); ok && tone != "" {
+		letterReq.Tone = tone
+	}
+	if length, ok := req.Params.Message.Metadata["length"].(string); ok && length != "" {
+		letterReq.Length = length
+	}
+
+	letter, err := h.executor.CoverLetter(ctx, req.Params.Message.ContextID, letterReq, input.resume)
+	if errors.Is(err, agent.ErrNoResults) || errors.Is(err, agent.ErrUnknownJob) {
+		return taskOutcome{state: TaskStateRejected, reply: agentMessage(err.Error(), nil)}
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Cover letter failed")
+		return failedOutcome(i18n.Detect(input.text))
+	}
+
+	reply := agentMessage(i18n.T(letter.Language, "cover_letter_ready", letter.Job.Title, letter.Job.Organization),
+		map[string]any{"language": letter.Language, "tone": letter.Tone, "length": letter.Length})
+	artifact := a2a.Artifact{
+		ArtifactID: generateID("artifact"),
+		Name:       "cover-letter.md",
+		Parts: []a2a.MessagePart{{
+			Kind:     "text",
+			Text:     letter.Markdown,
+			Metadata: map[string]interface{}{"mimeType": markdownMode(input.outputModes), "jobId": string(letter.Job.ID), "jobUrl": letter.Job.SourceUrl},
+		}},
+	}
+	return taskOutcome{state: TaskStateCompleted, reply: reply, artifacts: []a2a.Artifact{artifact}}
+}
+
+// failedOutcome reports a failure the client cannot act on. The cause, which
+// may carry upstream and model error text, is only logged by the caller.
+func failedOutcome(lang string) taskOutcome {
+	return taskOutcome{
+		state:    TaskStateFailed,
+		reply:    agentMessage(i18n.T(lang, "request_failed"), map[string]any{"language": i18n.Normalize(lang)}),
+		metadata: map[string]interface{}{"error": "request_failed"},
+	}
+}
+
+func agentMessage(text string, metadata map[string]any) Message {
+	return Message{
+		Role:      "agent",
+		Parts:     []Part{{Kind: "text", Text: text}},
+		MessageID: generateMessageID(),
+		Kind:      "message",
+		Metadata:  metadata,
+	}
+}
+
+func toA2AMessage(m Message) a2a.A2AMessage {
+	parts := make([]a2a.MessagePart, 0, len(m.Parts))
+	for _, p := range m.Parts {
+		part := a2a.MessagePart{Kind: p.Kind, Text: p.Text}
+		if len(p.Data) > 0 {
+			part.Data = p.Data
+		}
+		if p.File != nil {
+			// Keep the history small; the file's content is not repeated
+			part.Metadata = map[string]interface{}{"name": p.File.Name, "mimeType": p.File.MimeType}
+		}
+		parts = append(parts, part)
+	}
+	return a2a.A2AMessage{
+		Role:             m.Role,
+		Parts:            parts,
+		Metadata:         m.Metadata,
+		Extensions:       m.Extensions,
+		ReferenceTaskIds: m.ReferenceTaskIDs,
+		MessageID:        m.MessageID,
+		TaskID:           m.TaskID,
+		ContextID:        m.ContextID,
+		Kind:             "message",
+	}
 }
 
 func (h *A2AHandler) sendError(w http.ResponseWriter, id interface{}, code int, message string) {
@@ -221,12 +481,12 @@ func (h *A2AHandler) sendError(w http.ResponseWriter, id interface{}, code int,
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *A2AHandler) formatJobs(jobs []scraper.JobPosting) string {
+func (h *A2AHandler) formatJobs(jobs []scraper.JobPosting, lang string) string {
 	if len(jobs) == 0 {
-		return "No jobs found matching your criteria. Try different search terms or a broader location."
+		return i18n.T(lang, "no_results")
 	}
 
-	response := fmt.Sprintf("✨ Found %d job opportunities:\n\n", len(jobs))
+	response := i18n.T(lang, "found_jobs", len(jobs)) + "\n\n"
 	limit := 5
 	if len(jobs) < limit {
 		limit = len(jobs)
@@ -234,27 +494,52 @@ func (h *A2AHandler) formatJobs(jobs []scraper.JobPosting) string {
 
 	for i := 0; i < limit; i++ {
 		job := jobs[i]
-		location := "Remote"
+		location := i18n.T(lang, "remote")
 		if len(job.JobLocation) > 0 {
 			location = job.JobLocation[0]
 		}
 
-		response += fmt.Sprintf("%d. **%s** at %s\n", i+1, job.Title, job.Organization)
+		response += i18n.T(lang, "job_line", i+1, job.Title, job.Organization) + "\n"
 		response += fmt.Sprintf("   📍 %s", location)
 		if job.Remote {
-			response += " (Remote Available)"
+			response += i18n.T(lang, "remote_available")
+		}
+		if job.MatchReason != "" {
+			response += "\n" + i18n.T(lang, "match_line", job.MatchScore, job.MatchReason)
+		}
+		for _, bullet := range job.Summary {
+			response += "\n   • " + bullet
 		}
 		response += fmt.Sprintf("\n   🔗 %s\n\n", job.SourceUrl)
 	}
 
 	if len(jobs) > limit {
-		response += fmt.Sprintf("... and %d more jobs available!\n", len(jobs)-limit)
-		response += "Try refining your search for more specific results."
+		response += i18n.T(lang, "more_jobs", len(jobs)-limit) + "\n"
+		response += i18n.T(lang, "refine_hint")
 	}
 
 	return response
 }
 
+func isRejectedInput(err error) bool {
+	return errors.Is(err, agent.ErrPromptInjection) || errors.Is(err, agent.ErrInputTooLong) || errors.Is(err, agent.ErrEmptyInput) ||
+		errors.Is(err, agent.ErrNoProfile) || errors.Is(err, agent.ErrInvalidQuery)
+}
+
+func describeQuery(q scraper.JobQuery, lang string) string {
+	desc := q.Title
+	if q.Remote != nil && *q.Remote {
+		desc = i18n.T(lang, "query_remote", desc)
+	}
+	if q.Location != "" {
+		desc = i18n.T(lang, "query_in", desc, q.Location)
+	}
+	if len(q.ExcludeOrganizations) > 0 {
+		desc = i18n.T(lang, "query_excluding", desc, strings.Join(q.ExcludeOrganizations, ", "))
+	}
+	return desc
+}
+
 func maskKey(key string) string {
 	if len(key) <= 8 {
 		return "***"
@@ -263,5 +548,11 @@ func maskKey(key string) string {
 }
 
 func generateMessageID() string {
-	return fmt.Sprintf("msg_%d", time.Now().UnixNano())
+	return generateID("msg")
+}
+
+// generateID returns a random id: task ids are all a caller needs to read
+// or cancel a task, so they must not be guessable.
+func generateID(prefix string) string {
+	return prefix + "_" + rand.Text()
 }
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"strings"
+
+	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/justinndidit/job-agent/internal/semantic"
+	"github.com/rs/zerolog"
+)
+
+type AdminHandler struct {
+	usage  *agent.UsageTracker
+	gemini *agent.GeminiAgent
+	index  *semantic.Index
+	apiKey string
+	open   bool
+	logger *zerolog.Logger
+}
+
+func NewAdminHandler(usage *agent.UsageTracker, gemini *agent.GeminiAgent, apiKey string, logger *zerolog.Logger) *AdminHandler {
+	if apiKey == "" {
+		logger.Warn().Msg("ADMIN_API_KEY not set - admin endpoints are disabled")
+	}
+	return &AdminHandler{usage: usage, gemini: gemini, apiKey: apiKey, logger: logger}
+}
+
+// UseIndex enables GET /admin/index.
+func (h *AdminHandler) UseIndex(idx *semantic.Index) {
+	h.index = idx
+}
+
+// AllowUnauthenticated opens the admin routes to anyone when no API key is
+// set, for local development.
+func (h *AdminHandler) AllowUnauthenticated(allow bool) {
+	h.open = allow
+	if allow && h.apiKey == "" {
+		h.logger.Warn().Msg("ADMIN_ALLOW_UNAUTHENTICATED set - admin endpoints are unauthenticated")
+	}
+}
+
+// RequireKey guards admin routes with "Authorization: Bearer <ADMIN_API_KEY>".
+// Without a key the routes answer 404, unless AllowUnauthenticated is set.
+func (h *AdminHandler) RequireKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case h.apiKey != "":
+			key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(key), []byte(h.apiKey)) != 1 {
+				h.logger.Warn().Str("key", maskKey(key)).Msg("Invalid admin API key")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		case !h.open:
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Usage reports LLM token usage and cost (GET /admin/usage)
+func (h *AdminHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.usage.Report())
+}
+
+// Metrics exposes expvar counters (GET /admin/metrics)
+func (h *AdminHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+// Models reports the health of each model in the fallback chain (GET /admin/models)
+func (h *AdminHandler) Models(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"primary": h.gemini.Model(),
+		"models":  h.gemini.ModelHealth(),
+	})
+}
+
+// Cache reports query cache hit/miss statistics (GET /admin/cache)
+func (h *AdminHandler) Cache(w http.ResponseWriter, r *http.Request) {
+	stats := h.gemini.CacheStats()
+	if stats == nil {
+		http.Error(w, "Query cache disabled", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// Index reports the semantic index size, and with ?q= runs a similarity
+// search over it (GET /admin/index)
+func (h *AdminHandler) Index(w http.ResponseWriter, r *http.Request) {
+	if h.index == nil {
+		http.Error(w, "Semantic index disabled", http.StatusNotFound)
+		return
+	}
+	response := map[string]interface{}{"stats": h.index.Stats()}
+	if q := r.URL.Query().Get("q"); q != "" {
+		matches, err := h.index.Search(r.Context(), q, 10, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		response["matches"] = matches
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAdminRequireKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		open   bool
+		header string
+		want   int
+	}{
+		{"no key configured", "", false, "", http.StatusNotFound},
+		{"no key configured, any header", "", false, "Bearer guess", http.StatusNotFound},
+		{"no key configured, open for development", "", true, "", http.StatusOK},
+		{"missing key", "secret", false, "", http.StatusUnauthorized},
+		{"wrong key", "secret", false, "Bearer wrong", http.StatusUnauthorized},
+		{"wrong key, open flag ignored", "secret", true, "", http.StatusUnauthorized},
+		{"right key", "secret", false, "Bearer secret", http.StatusOK},
+	}
+	log := zerolog.Nop()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewAdminHandler(nil, nil, tt.apiKey, &log)
+			h.AllowUnauthenticated(tt.open)
+			r := httptest.NewRequest(http.MethodGet, "/admin/usage", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.RequireKey(ok).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
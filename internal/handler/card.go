@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+)
+
+const (
+	protocolVersion = "0.3.0"
+	agentVersion    = "1.0.0"
+
+	apiKeyHeader = "X-AGENT-API-KEY"
+)
+
+// baseSkills are the skills every deployment has.
+var baseSkills = []a2a.AgentSkill{
+	{
+		ID:          "job_search",
+		Name:        "Job Search",
+		Description: "Search for jobs by title and location using natural language, or with a structured query as a data part (schema at " + JobQuerySchemaPath + ")",
+		Tags:        []string{"jobs", "search"},
+		Examples:    []string{"Find software engineer jobs in New York", "only remote ones", "exclude Amazon"},
+		InputModes:  []string{"text/plain", "application/json"},
+		OutputModes: []string{"text/plain", "application/json"},
+	},
+	{
+		ID:          "cover_letter",
+		Name:        "Cover Letter",
+		Description: "Draft a tailored cover letter for a job from the last results",
+		Tags:        []string{"writing", "cover letter"},
+		Examples:    []string{"write a short, friendly cover letter for #2"},
+		InputModes:  []string{"text/plain", "text/markdown"},
+		OutputModes: []string{"text/markdown"},
+	},
+}
+
+// premiumSkills read and rank against attached documents, which costs far
+// more model time than a search. Only the authenticated extended card lists
+// them, with their rate limits.
+var premiumSkills = []a2a.AgentSkill{
+	{
+		ID:          "resume_match",
+		Name:        "Resume Match",
+		Description: "Attach a resume and ask for jobs that fit; results are ranked by fit with a short explanation per job",
+		Tags:        []string{"jobs", "resume", "ranking"},
+		Examples:    []string{"Which jobs fit my resume?"},
+		InputModes:  []string{"text/plain", "text/markdown", "text/html", "application/pdf"},
+		OutputModes: []string{"text/plain", "application/json"},
+	},
+	{
+		ID:          "similar_roles",
+		Name:        "Similar Roles",
+		Description: "Attach a job description (or its URL as a file uri) and ask for similar roles; results are ranked by the skills it asks for",
+		Tags:        []string{"jobs", "search", "similarity"},
+		Examples:    []string{"Find roles similar to this one"},
+		InputModes:  []string{"text/plain", "text/markdown", "text/html"},
+		OutputModes: []string{"text/plain", "application/json"},
+	},
+}
+
+// RegisterSkill adds a skill to the agent card, replacing one with the
+// same id.
+func (h *A2AHandler) RegisterSkill(skill a2a.AgentSkill) {
+	h.skills = putSkill(h.skills, skill)
+}
+
+// RegisterPremiumSkill adds a skill to the authenticated extended card
+// only, limited to perMinute requests (0 for no limit).
+func (h *A2AHandler) RegisterPremiumSkill(skill a2a.AgentSkill, perMinute int) {
+	skill.RateLimit = nil
+	if perMinute > 0 {
+		skill.RateLimit = &a2a.RateLimit{RequestsPerMinute: perMinute}
+	}
+	h.premium = putSkill(h.premium, skill)
+	h.limits.set(skill.ID, perMinute)
+}
+
+func putSkill(skills []a2a.AgentSkill, skill a2a.AgentSkill) []a2a.AgentSkill {
+	for i, s := range skills {
+		if s.ID == skill.ID {
+			skills[i] = skill
+			return skills
+		}
+	}
+	return append(skills, skill)
+}
+
+// Card describes the agent as it is configured: its URL and provider come
+// from the config, capabilities and security from what is enabled. Only the
+// extended card lists the premium skills.
+func (h *A2AHandler) Card(extended bool) a2a.AgentCard {
+	card := a2a.AgentCard{
+		ProtocolVersion:    protocolVersion,
+		Name:               "Job Search Agent",
+		Description:        "AI-powered job search agent that finds relevant job postings using natural language queries",
+		URL:                h.cfg.BaseURL,
+		PreferredTransport: "JSONRPC",
+		Version:            agentVersion,
+		Provider:           &a2a.AgentProvider{Organization: h.cfg.Organization, URL: h.cfg.BaseURL},
+		Capabilities: a2a.AgentCapabilities{
+			Streaming:         true,
+			PushNotifications: h.push != nil,
+		},
+		DefaultInputModes:                 []string{"text/plain"},
+		DefaultOutputModes:                []string{"text/plain", "application/json"},
+		Skills:                            append([]a2a.AgentSkill(nil), h.skills...),
+		SupportsAuthenticatedExtendedCard: h.telexAPIKey != "",
+	}
+	if extended {
+		card.Skills = append(card.Skills, h.premium...)
+	}
+	if h.telexAPIKey != "" {
+		card.SecuritySchemes = map[string]a2a.SecurityScheme{
+			"apiKey": {Type: "apiKey", In: "header", Name: apiKeyHeader, Description: "API key issued by the agent's operator"},
+		}
+		card.Security = []map[string][]string{{"apiKey": {}}}
+	}
+	return card
+}
+
+// AgentCard returns the A2A agent card (GET /.well-known/agent.json)
+func (h *A2AHandler) AgentCard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Card(false))
+}
+
+// handleExtendedCard returns the card with the premium skills
+// (agent/getAuthenticatedExtendedCard). HandleA2A has already checked the
+// API key.
+func (h *A2AHandler) handleExtendedCard(w http.ResponseWriter, req *A2ARequest) {
+	if h.telexAPIKey == "" {
+		h.sendError(w, req.ID, errExtendedCardNotConfigured, "Authenticated Extended Card is not configured")
+		return
+	}
+	h.sendResult(w, req.ID, h.Card(true))
+}
+
+// authenticated checks the API key the card's security scheme asks for,
+// answering 401 when it is missing or wrong.
+func (h *A2AHandler) authenticated(w http.ResponseWriter, r *http.Request) bool {
+	if h.telexAPIKey == "" {
+		return true
+	}
+	apiKey := r.Header.Get(apiKeyHeader)
+	if subtle.ConstantTimeCompare([]byte(apiKey), []byte(h.telexAPIKey)) == 1 {
+		return true
+	}
+
+	message := "Invalid API key"
+	if apiKey == "" {
+		message = "Missing " + apiKeyHeader + " header"
+	} else {
+		h.logger.Warn().Str("key", maskKey(apiKey)).Msg("Invalid API key")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(A2AResponse{JSONRPC: "2.0", Error: &A2AError{Code: -32600, Message: message}})
+	return false
+}
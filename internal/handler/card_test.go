@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+)
+
+// cardSchema is the AgentCard definition of the A2A JSON schema.
+func cardSchema(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile("testdata/agent-card.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// validate checks v against the subset of JSON schema the A2A schema and
+// the job query schema use: $ref, type, properties, required,
+// additionalProperties, items, maxItems, maxLength, minimum, maximum,
+// enum, const and anyOf.
+func validate(root, schema map[string]any, v any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := root["definitions"].(map[string]any)[strings.TrimPrefix(ref, "#/definitions/")]
+		if def == nil {
+			return []string{path + ": unknown $ref " + ref}
+		}
+		return validate(root, def.(map[string]any), v, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var all []string
+		for _, s := range anyOf {
+			errs := validate(root, s.(map[string]any), v, path)
+			if len(errs) == 0 {
+				return nil
+			}
+			all = append(all, errs...)
+		}
+		return []string{fmt.Sprintf("%s: matches none of anyOf: %v", path, all)}
+	}
+
+	var errs []string
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{path + ": not an object"}
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing %q", path, name))
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for name, value := range obj {
+			if prop, ok := props[name]; ok {
+				errs = append(errs, validate(root, prop.(map[string]any), value, path+"."+name)...)
+			} else if extra, ok := schema["additionalProperties"].(map[string]any); ok {
+				errs = append(errs, validate(root, extra, value, path+"."+name)...)
+			} else if schema["additionalProperties"] == false {
+				errs = append(errs, fmt.Sprintf("%s: unexpected %q", path, name))
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return []string{path + ": not an array"}
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range arr {
+				errs = append(errs, validate(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+		if n, ok := schema["maxItems"].(float64); ok && float64(len(arr)) > n {
+			errs = append(errs, fmt.Sprintf("%s: more than %v items", path, n))
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return []string{path + ": not a string"}
+		}
+		if n, ok := schema["maxLength"].(float64); ok && float64(len([]rune(s))) > n {
+			errs = append(errs, fmt.Sprintf("%s: longer than %v", path, n))
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != float64(int(n)) {
+			return []string{path + ": not an integer"}
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			errs = append(errs, fmt.Sprintf("%s: %v is below %v", path, n, min))
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			errs = append(errs, fmt.Sprintf("%s: %v is above %v", path, n, max))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{path + ": not a boolean"}
+		}
+	}
+	if c, ok := schema["const"]; ok && v != c {
+		errs = append(errs, fmt.Sprintf("%s: %v is not %v", path, v, c))
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, v) {
+		errs = append(errs, fmt.Sprintf("%s: %v not in %v", path, v, enum))
+	}
+	return errs
+}
+
+func checkCard(t *testing.T, schema map[string]any, card any) {
+	t.Helper()
+	data, err := json.Marshal(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range validate(schema, schema, v, "card") {
+		t.Error(e)
+	}
+}
+
+func skillIDs(card a2a.AgentCard) []string {
+	var ids []string
+	for _, s := range card.Skills {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestAgentCardSchema(t *testing.T) {
+	schema := cardSchema(t)
+	base := []string{"job_search", "cover_letter"}
+	all := []string{"job_search", "cover_letter", "resume_match", "similar_roles"}
+
+	tests := []struct {
+		name         string
+		apiKey       string
+		extended     bool
+		skills       []string
+		secured      bool
+		extendedCard bool
+	}{
+		{name: "public card with key", apiKey: "key", skills: base, secured: true, extendedCard: true},
+		{name: "extended card with key", apiKey: "key", extended: true, skills: all, secured: true, extendedCard: true},
+		{name: "public card without key", skills: base},
+		{name: "extended card without key", extended: true, skills: all},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, config.A2AConfig{
+				BaseURL: "https://jobs.example.com", Organization: "Example", APIKey: tt.apiKey, PremiumRateLimit: 5,
+			})
+			card := h.Card(tt.extended)
+			checkCard(t, schema, card)
+
+			if got := skillIDs(card); !slices.Equal(got, tt.skills) {
+				t.Errorf("skills = %v, want %v", got, tt.skills)
+			}
+			if card.URL != "https://jobs.example.com" || card.Provider.Organization != "Example" {
+				t.Errorf("url = %q, provider = %+v", card.URL, card.Provider)
+			}
+			if secured := card.SecuritySchemes["apiKey"].Name == apiKeyHeader && len(card.Security) == 1; secured != tt.secured {
+				t.Errorf("security schemes = %+v, security = %v", card.SecuritySchemes, card.Security)
+			}
+			if card.SupportsAuthenticatedExtendedCard != tt.extendedCard {
+				t.Errorf("supportsAuthenticatedExtendedCard = %v", card.SupportsAuthenticatedExtendedCard)
+			}
+			for _, s := range card.Skills {
+				premium := s.ID == "resume_match" || s.ID == "similar_roles"
+				if premium != (s.RateLimit != nil) {
+					t.Errorf("skill %s rate limit = %+v", s.ID, s.RateLimit)
+				}
+			}
+		})
+	}
+}
+
+func TestAgentCardEndpoints(t *testing.T) {
+	schema := cardSchema(t)
+	h := newTestHandler(t, config.A2AConfig{APIKey: "key", PremiumRateLimit: 5})
+
+	w := httptest.NewRecorder()
+	h.AgentCard(w, httptest.NewRequest(http.MethodGet, "/.well-known/agent.json", nil))
+	var public map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &public); err != nil {
+		t.Fatal(err)
+	}
+	checkCard(t, schema, public)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"agent/getAuthenticatedExtendedCard"}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	h.HandleA2A(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without key: status = %d, want 401", w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set(apiKeyHeader, "key")
+	w = httptest.NewRecorder()
+	h.HandleA2A(w, r)
+	var resp struct {
+		Result map[string]any `json:"result"`
+		Error  *A2AError      `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error != nil {
+		t.Fatalf("extended card: %s", w.Body.String())
+	}
+	checkCard(t, schema, resp.Result)
+	if n := len(resp.Result["skills"].([]any)); n != 4 {
+		t.Errorf("extended card lists %d skills, want 4", n)
+	}
+
+	open := newTestHandler(t, config.A2AConfig{})
+	w = postA2A(open, body)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error == nil || resp.Error.Code != errExtendedCardNotConfigured {
+		t.Errorf("without TELEX_API_KEY: %s", w.Body.String())
+	}
+}
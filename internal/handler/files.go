@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/justinndidit/job-agent/internal/pkg/safehttp"
+)
+
+// maxFileBytes bounds an attached document; more would be truncated before
+// reaching the model anyway. HTML pages carry markup, so they get more room.
+const (
+	maxFileBytes = 4 * agent.MaxResumeRunes
+	maxHTMLBytes = 1 << 20
+)
+
+// What an attached document is for
+const (
+	roleResume         = "resume"
+	roleJobDescription = "job_description"
+)
+
+var (
+	// Messages asking for roles like an attached posting
+	similarRequest = regexp.MustCompile(`(?i)\b(similar|like (this|that|it)|job description|this (job|role|posting|position|offer)|comparable)\b`)
+
+	htmlDropped = regexp.MustCompile(`(?is)<(script|style|noscript|svg|head)\b.*?</(script|style|noscript|svg|head)\s*>`)
+	htmlBreaks  = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr)\b[^>]*>`)
+	htmlTags    = regexp.MustCompile(`<[^>]*>`)
+)
+
+// FetchFileURIs lets file parts refer to documents by http(s) URI, which
+// are downloaded with the same limits as inline files.
+func (h *A2AHandler) FetchFileURIs(timeout time.Duration, allowPrivate bool) {
+	h.files = safehttp.NewClient(timeout, allowPrivate)
+	h.filesAllowPrivate = allowPrivate
+}
+
+// fileText returns an attached document's text. Only text formats are
+// accepted: PDFs must arrive as their extracted text.
+func (h *A2AHandler) fileText(ctx context.Context, f *FilePart) (string, *A2AError) {
+	invalid := func(format string, args ...any) *A2AError {
+		return &A2AError{Code: -32602, Message: "Unsupported file: " + fmt.Sprintf(format, args...)}
+	}
+
+	var data []byte
+	mimeType := f.MimeType
+	switch {
+	case f.Bytes != "" && f.URI != "":
+		return "", invalid("send either bytes or uri, not both")
+	case f.Bytes != "":
+		if len(f.Bytes) > base64.StdEncoding.EncodedLen(maxHTMLBytes) {
+			return "", invalid("file exceeds %d bytes", maxHTMLBytes)
+		}
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(f.Bytes); err != nil {
+			return "", invalid("invalid base64 content: %v", err)
+		}
+	case f.URI != "":
+		if h.files == nil {
+			return "", invalid("file URIs are not enabled, send the file inline as bytes")
+		}
+		fetched, fetchedType, err := h.fetchFile(ctx, f.URI)
+		if err != nil {
+			return "", invalid("%v", err)
+		}
+		data = fetched
+		if mimeType == "" {
+			mimeType = fetchedType
+		}
+	default:
+		return "", invalid("file is empty")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	if mediaType == "" {
+		// Untyped content must look like text
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	limit := maxFileBytes
+	switch mediaType {
+	case "text/plain", "text/markdown", "text/x-markdown", "application/pdf":
+	case "text/html":
+		limit = maxHTMLBytes
+	default:
+		return "", &A2AError{Code: errContentTypeNotSupported, Message: fmt.Sprintf("Unsupported file type %q: send text/plain, text/markdown, text/html or a PDF's extracted text", mediaType)}
+	}
+	if len(data) > limit {
+		return "", invalid("file exceeds %d bytes", limit)
+	}
+	if bytes.HasPrefix(data, []byte("%PDF")) || !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "", invalid("binary content; send the document's text instead")
+	}
+
+	text := string(data)
+	if mediaType == "text/html" {
+		text = htmlText(text)
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", invalid("file has no text")
+	}
+	return text, nil
+}
+
+// fetchFile downloads a file URI, reading at most maxHTMLBytes.
+func (h *A2AHandler) fetchFile(ctx context.Context, uri string) ([]byte, string, error) {
+	if err := safehttp.CheckURL(uri, h.filesAllowPrivate); err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", "TelexJobAgent/1.0")
+	req.Header.Set("Accept", "text/plain, text/markdown, text/html;q=0.9")
+
+	resp, err := h.files.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not fetch %s: %w", uri, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetching %s returned %s", uri, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTMLBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read %s: %w", uri, err)
+	}
+	if len(data) > maxHTMLBytes {
+		return nil, "", fmt.Errorf("file exceeds %d bytes", maxHTMLBytes)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// fileRole tells a resume from a job description: by the part's "purpose"
+// metadata, then the file name, then what the message asks for. Resumes
+// are the default.
+func fileRole(part Part, message string) string {
+	if purpose, ok := part.Metadata["purpose"].(string); ok {
+		switch strings.ToLower(purpose) {
+		case "job_description", "job", "posting":
+			return roleJobDescription
+		case "resume", "cv":
+			return roleResume
+		}
+	}
+
+	name := strings.ToLower(part.File.Name)
+	for _, hint := range []string{"resume", "résumé", "cv", "curriculum"} {
+		if strings.Contains(name, hint) {
+			return roleResume
+		}
+	}
+	for _, hint := range []string{"job", "jd", "description", "posting", "vacancy", "role"} {
+		if strings.Contains(name, hint) {
+			return roleJobDescription
+		}
+	}
+
+	if similarRequest.MatchString(message) {
+		return roleJobDescription
+	}
+	return roleResume
+}
+
+// htmlText reduces a web page to its visible text, one block per line.
+func htmlText(page string) string {
+	page = htmlDropped.ReplaceAllString(page, " ")
+	page = htmlBreaks.ReplaceAllString(page, "\n")
+	page = html.UnescapeString(htmlTags.ReplaceAllString(page, " "))
+
+	var lines []string
+	for _, line := range strings.Split(page, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/justinndidit/job-agent/internal/pkg/safehttp"
+)
+
+const resumeText = "Jane Doe\nGo developer, 6 years\nSkills: Go, PostgreSQL, Kubernetes"
+
+func encoded(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestFileText(t *testing.T) {
+	tests := []struct {
+		name string
+		file FilePart
+		// code is the expected error code, 0 for text
+		code    int
+		message string
+	}{
+		{"plain text", FilePart{MimeType: "text/plain", Bytes: encoded(resumeText)}, 0, ""},
+		{"untyped text", FilePart{Bytes: encoded(resumeText)}, 0, ""},
+		{"html", FilePart{MimeType: "text/html", Bytes: encoded("<p>Jane Doe</p><script>x()</script>")}, 0, ""},
+		{"text over the limit", FilePart{MimeType: "text/plain", Bytes: encoded(strings.Repeat("a", maxFileBytes+1))}, -32602, "exceeds"},
+		{"html over the limit", FilePart{MimeType: "text/html", Bytes: encoded(strings.Repeat("a", maxHTMLBytes+1))}, -32602, "exceeds"},
+		{"encoding over the limit", FilePart{MimeType: "text/html", Bytes: strings.Repeat("A", base64.StdEncoding.EncodedLen(maxHTMLBytes)+4)}, -32602, "exceeds"},
+		{"bad base64", FilePart{MimeType: "text/plain", Bytes: "not base64!"}, -32602, "invalid base64"},
+		{"zip", FilePart{MimeType: "application/zip", Bytes: encoded("PK\x03\x04")}, errContentTypeNotSupported, "application/zip"},
+		{"untyped image", FilePart{Bytes: encoded("\x89PNG\r\n\x1a\n")}, errContentTypeNotSupported, "image/png"},
+		{"pdf bytes", FilePart{MimeType: "application/pdf", Bytes: encoded("%PDF-1.7")}, -32602, "binary"},
+		{"both bytes and uri", FilePart{Bytes: encoded(resumeText), URI: "https://example.com/cv.txt"}, -32602, "either"},
+		{"uri when not enabled", FilePart{URI: "https://example.com/cv.txt"}, -32602, "not enabled"},
+		{"empty", FilePart{MimeType: "text/plain"}, -32602, "empty"},
+	}
+
+	h := newTestHandler(t, config.A2AConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, rpcErr := h.fileText(context.Background(), &tt.file)
+			if tt.code == 0 {
+				if rpcErr != nil {
+					t.Fatalf("error %d: %s", rpcErr.Code, rpcErr.Message)
+				}
+				if !strings.Contains(text, "Jane Doe") || strings.Contains(text, "<") {
+					t.Errorf("text = %q", text)
+				}
+				return
+			}
+			if rpcErr == nil {
+				t.Fatalf("got text %.40q, want error %d", text, tt.code)
+			}
+			if rpcErr.Code != tt.code || !strings.Contains(rpcErr.Message, tt.message) {
+				t.Errorf("error %d %q, want %d containing %q", rpcErr.Code, rpcErr.Message, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestFileTextFetchesURI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cv.txt":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, resumeText)
+		case "/cv.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			fmt.Fprint(w, "PK\x03\x04")
+		case "/moved":
+			http.Redirect(w, r, "/cv.txt", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	h := newTestHandler(t, config.A2AConfig{})
+	h.FetchFileURIs(time.Second, true)
+	text, rpcErr := h.fileText(context.Background(), &FilePart{URI: srv.URL + "/cv.txt"})
+	if rpcErr != nil {
+		t.Fatalf("fetching %s: %s", srv.URL, rpcErr.Message)
+	}
+	if text != resumeText {
+		t.Errorf("text = %q, want %q", text, resumeText)
+	}
+
+	for path, code := range map[string]int{
+		"/cv.zip":  errContentTypeNotSupported,
+		"/missing": -32602,
+		// Redirects are not followed, they could lead past the address check
+		"/moved": -32602,
+	} {
+		if _, rpcErr := h.fileText(context.Background(), &FilePart{URI: srv.URL + path}); rpcErr == nil || rpcErr.Code != code {
+			t.Errorf("%s: error %v, want %d", path, rpcErr, code)
+		}
+	}
+
+	h.FetchFileURIs(time.Second, false)
+	for _, uri := range []string{srv.URL + "/cv.txt", "http://localhost/cv.txt", "http://169.254.169.254/latest/meta-data"} {
+		_, rpcErr := h.fileText(context.Background(), &FilePart{URI: uri})
+		if rpcErr == nil || rpcErr.Code != -32602 || !strings.Contains(rpcErr.Message, safehttp.ErrPrivateURL.Error()) {
+			t.Errorf("%s: error %v, want a private address rejection", uri, rpcErr)
+		}
+	}
+}
+
+func TestResumeFileRoutesToResumeMatch(t *testing.T) {
+	h := newTestHandler(t, config.A2AConfig{PremiumRateLimit: 1})
+	params := A2AParams{Message: Message{
+		Kind: "message", Role: "user", MessageID: "m1",
+		Parts: []Part{
+			{Kind: "text", Text: "which of these jobs fit me?"},
+			{Kind: "file", File: &FilePart{Name: "notes.txt", MimeType: "text/plain", Bytes: encoded(resumeText)}},
+		},
+	}}
+	input, rpcErr := h.parseInput(context.Background(), params)
+	if rpcErr != nil {
+		t.Fatalf("parseInput: %s", rpcErr.Message)
+	}
+	if input.resume != resumeText || input.jobDescription != "" {
+		t.Errorf("resume = %q, job description = %q", input.resume, input.jobDescription)
+	}
+	if skill := input.skill(); skill != "resume_match" {
+		t.Errorf("skill = %q, want resume_match", skill)
+	}
+
+	// Through HandleA2A: with resume_match's one request spent, the same
+	// message is turned away by that skill's limit
+	const caller = "203.0.113.1"
+	h.limits.take("resume_match", caller)
+	body := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "message/send", "params": {"message": {
+		"kind": "message", "role": "user", "messageId": "m1", "parts": [
+		{"kind": "text", "text": "which of these jobs fit me?"},
+		{"kind": "file", "file": {"name": "notes.txt", "mimeType": "text/plain", "bytes": %q}}]}}}`, encoded(resumeText))
+	w := postA2AFrom(h, caller+":1234", body)
+	if code := rpcErrorCode(t, w.Body.Bytes()); code != errRateLimited || !strings.Contains(w.Body.String(), "resume_match") {
+		t.Errorf("got %s, want the resume_match rate limit", w.Body)
+	}
+}
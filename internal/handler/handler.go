@@ -1,9 +1,15 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"net/netip"
+	"strings"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/justinndidit/job-agent/internal/agent"
 	"github.com/justinndidit/job-agent/internal/scraper"
 	"github.com/rs/zerolog"
@@ -47,6 +53,8 @@ type JobSearchResponse struct {
 	Success bool                 `json:"success"`
 	Count   int                  `json:"count"`
 	Jobs    []scraper.JobPosting `json:"jobs"`
+	// SearchedTitles lists the expanded titles, when expansion applied
+	SearchedTitles []string `json:"searched_titles,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -66,7 +74,26 @@ func (h *Handler) SearchJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := h.executor.SearchJobTool(r.Context(), req.Query)
+	result, err := h.executor.Search(usageContext(r, ""), "", scraper.JobQuery{}, req.Query)
+	var clarify *agent.ClarificationError
+	if errors.As(err, &clarify) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error:   "Clarification needed",
+			Message: clarify.Question,
+		})
+		return
+	}
+	if isRejectedInput(err) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error:   "Message rejected",
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -79,8 +106,67 @@ func (h *Handler) SearchJobs(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(JobSearchResponse{
-		Success: true,
-		Count:   len(jobs),
-		Jobs:    jobs,
+		Success:        true,
+		Count:          len(result.Jobs),
+		Jobs:           result.Jobs,
+		SearchedTitles: result.Query.Titles,
 	})
 }
+
+// usageContext tags LLM calls made while serving r with the request id,
+// caller and A2A context for cost attribution.
+func usageContext(r *http.Request, contextID string) context.Context {
+	return agent.WithUsageScope(r.Context(), agent.UsageScope{
+		RequestID: middleware.GetReqID(r.Context()),
+		Caller:    callerID(r),
+		ContextID: contextID,
+	})
+}
+
+// callerID identifies the caller by client address, as set by ClientAddr.
+// The API key cannot tell callers apart: without TELEX_API_KEY anyone can
+// send a new one with each request, and with it every caller sends the
+// same one.
+func callerID(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ClientAddr sets the request's RemoteAddr to the client address given in
+// X-Forwarded-For, but only when the request comes from one of the trusted
+// proxies. The client is the last address in the header that is not a
+// trusted proxy itself, as earlier ones can be forged by the client.
+func ClientAddr(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, err := netip.ParseAddrPort(r.RemoteAddr)
+			if err != nil || !isTrusted(peer.Addr()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				if !isTrusted(addr) {
+					r.RemoteAddr = netip.AddrPortFrom(addr.Unmap(), 0).String()
+					break
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
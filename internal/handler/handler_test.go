@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientAddr(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		want      string
+	}{
+		{"direct", "203.0.113.1:1234", nil, "203.0.113.1"},
+		{"forged header from an untrusted peer", "203.0.113.1:1234", []string{"198.51.100.7"}, "203.0.113.1"},
+		{"trusted proxy", "10.0.0.2:1234", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"client prepends a forged address", "10.0.0.2:1234", []string{"192.0.2.9, 198.51.100.7"}, "198.51.100.7"},
+		{"chain of trusted proxies", "10.0.0.2:1234", []string{"198.51.100.7, 10.0.0.3"}, "198.51.100.7"},
+		{"several headers", "10.0.0.2:1234", []string{"192.0.2.9", "198.51.100.7"}, "198.51.100.7"},
+		{"trusted proxy without header", "10.0.0.2:1234", nil, "10.0.0.2"},
+		{"garbage in the header", "10.0.0.2:1234", []string{"not-an-ip"}, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.peer
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			var got string
+			ClientAddr(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = callerID(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("caller %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallerIDIgnoresAPIKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.RemoteAddr = "203.0.113.1:1234"
+	r.Header.Set(apiKeyHeader, "made-up-key")
+	if got := callerID(r); got != "203.0.113.1" {
+		t.Errorf("caller %q, want the client address", got)
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestTimeout bounds every request but streams, which last as long as
+// their task.
+const RequestTimeout = 60 * time.Second
+
+const (
+	// maxRequestBytes leaves room for an inline file part
+	maxRequestBytes = 8 << 20
+
+	maxBatchSize     = 20
+	batchParallelism = 4
+)
+
+// Methods whose response is an event stream, which cannot be part of a batch
+var streamingMethods = map[string]bool{"message/stream": true, "tasks/resubscribe": true}
+
+// parseRequest checks a JSON-RPC 2.0 request object and decodes its
+// params. On error the returned request, if any, carries the id to answer
+// with; a nil request means the id could not be read and the answer uses
+// null.
+func parseRequest(raw json.RawMessage) (*A2ARequest, *A2AError) {
+	var envelope struct {
+		JSONRPC *string         `json:"jsonrpc"`
+		Method  *string         `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		ID      json.RawMessage `json:"id"`
+	}
+	if len(raw) == 0 || raw[0] != '{' || json.Unmarshal(raw, &envelope) != nil {
+		return nil, &A2AError{Code: -32600, Message: "Invalid Request: expected a JSON-RPC request object"}
+	}
+	if envelope.ID != nil && !validID(envelope.ID) {
+		return nil, &A2AError{Code: -32600, Message: "Invalid Request: id must be a string, a number or null"}
+	}
+
+	req := &A2ARequest{ID: envelope.ID}
+	if envelope.ID == nil {
+		req.notification = true
+		req.ID = nil
+	}
+	switch {
+	case envelope.JSONRPC == nil || *envelope.JSONRPC != "2.0":
+		return req, &A2AError{Code: -32600, Message: `Invalid Request: jsonrpc must be "2.0"`}
+	case envelope.Method == nil || *envelope.Method == "":
+		return req, &A2AError{Code: -32600, Message: "Invalid Request: method must be a non-empty string"}
+	}
+	req.JSONRPC, req.Method = *envelope.JSONRPC, *envelope.Method
+
+	if params := bytes.TrimSpace(envelope.Params); len(params) > 0 && !bytes.Equal(params, []byte("null")) {
+		if params[0] != '{' {
+			return req, &A2AError{Code: -32602, Message: "Invalid params: params must be an object"}
+		}
+		if err := json.Unmarshal(params, &req.Params); err != nil {
+			return req, &A2AError{Code: -32602, Message: "Invalid params: " + err.Error()}
+		}
+	}
+	return req, nil
+}
+
+func validID(id json.RawMessage) bool {
+	switch c := id[0]; {
+	case c == '"', c == '-', c >= '0' && c <= '9':
+		return true
+	default:
+		return bytes.Equal(id, []byte("null"))
+	}
+}
+
+// dispatch handles one request object, writing its response to w. It
+// reports false for notifications, which are handled without a response.
+// Malformed requests are always answered, since whether they were meant as
+// notifications cannot be told.
+func (h *A2AHandler) dispatch(w http.ResponseWriter, r *http.Request, raw json.RawMessage, inBatch bool) bool {
+	req, rpcErr := parseRequest(raw)
+	if rpcErr != nil {
+		if req != nil && req.notification && rpcErr.Code != -32600 {
+			return false
+		}
+		var id interface{}
+		if req != nil {
+			id = req.ID
+		}
+		h.logger.Warn().Int("code", rpcErr.Code).Str("error", rpcErr.Message).Msg("Rejected JSON-RPC request")
+		h.sendError(w, id, rpcErr.Code, rpcErr.Message)
+		return true
+	}
+
+	h.logger.Info().
+		Str("method", req.Method).
+		Str("role", req.Params.Message.Role).
+		Bool("notification", req.notification).
+		Bool("batch", inBatch).
+		Msg("A2A request received")
+
+	if inBatch && streamingMethods[req.Method] {
+		if req.notification {
+			return false
+		}
+		h.sendError(w, req.ID, -32600, "Invalid Request: "+req.Method+" cannot be batched")
+		return true
+	}
+	if req.notification {
+		h.route(&responseBuffer{header: make(http.Header)}, r, req)
+		return false
+	}
+	h.route(w, r, req)
+	return true
+}
+
+// handleBatch runs the requests of a batch concurrently, at most
+// batchParallelism at a time, and answers with their responses in request
+// order. A batch of notifications gets no response.
+func (h *A2AHandler) handleBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		h.sendError(w, nil, -32700, "Parse error")
+		return
+	}
+	if len(items) == 0 {
+		h.sendError(w, nil, -32600, "Invalid Request: empty batch")
+		return
+	}
+	if len(items) > maxBatchSize {
+		h.sendError(w, nil, -32600, fmt.Sprintf("Invalid Request: batches are limited to %d requests", maxBatchSize))
+		return
+	}
+
+	responses := make([][]byte, len(items))
+	sem := make(chan struct{}, batchParallelism)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			buf := &responseBuffer{header: make(http.Header)}
+			if h.dispatch(buf, r, bytes.TrimSpace(item), true) {
+				responses[i] = bytes.TrimSpace(buf.body.Bytes())
+			}
+		}()
+	}
+	wg.Wait()
+
+	var out [][]byte
+	for _, resp := range responses {
+		if resp != nil {
+			out = append(out, resp)
+		}
+	}
+	if len(out) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("["))
+	w.Write(bytes.Join(out, []byte(",")))
+	w.Write([]byte("]\n"))
+}
+
+// responseBuffer collects a response written by a method handler.
+type responseBuffer struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header         { return b.header }
+func (b *responseBuffer) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *responseBuffer) WriteHeader(int)             {}
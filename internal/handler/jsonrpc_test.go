@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func newTestHandler(t *testing.T, cfg config.A2AConfig) *A2AHandler {
+	t.Helper()
+	log := zerolog.Nop()
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "http://localhost:8080"
+	}
+	return NewA2AHandler(nil, cfg, &log)
+}
+
+func postA2A(h *A2AHandler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.HandleA2A(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return w
+}
+
+// rpcResponse is the part of a JSON-RPC response the tests look at.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *A2AError       `json:"error"`
+}
+
+func TestJSONRPCConformance(t *testing.T) {
+	tooMany := make([]string, maxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tasks/get","params":{"id":"t%d"}}`, i, i)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		// want is one "id:code" per expected response, in order; code 0
+		// means a result
+		want []string
+	}{
+		{
+			name:   "invalid JSON",
+			body:   `{"jsonrpc":"2.0",`,
+			status: http.StatusOK,
+			want:   []string{"null:-32700"},
+		},
+		{
+			name:   "wrong jsonrpc version",
+			body:   `{"jsonrpc":"1.0","id":1,"method":"tasks/get","params":{"id":"x"}}`,
+			status: http.StatusOK,
+			want:   []string{"1:-32600"},
+		},
+		{
+			name:   "missing jsonrpc",
+			body:   `{"id":"a","method":"tasks/get"}`,
+			status: http.StatusOK,
+			want:   []string{`"a":-32600`},
+		},
+		{
+			name:   "object id",
+			body:   `{"jsonrpc":"2.0","id":{"a":1},"method":"tasks/get"}`,
+			status: http.StatusOK,
+			want:   []string{"null:-32600"},
+		},
+		{
+			name:   "array id",
+			body:   `{"jsonrpc":"2.0","id":[1],"method":"tasks/get"}`,
+			status: http.StatusOK,
+			want:   []string{"null:-32600"},
+		},
+		{
+			name:   "null id is echoed",
+			body:   `{"jsonrpc":"2.0","id":null,"method":"tasks/get","params":{"id":"missing"}}`,
+			status: http.StatusOK,
+			want:   []string{"null:-32001"},
+		},
+		{
+			name:   "missing method",
+			body:   `{"jsonrpc":"2.0","id":2}`,
+			status: http.StatusOK,
+			want:   []string{"2:-32600"},
+		},
+		{
+			name:   "unknown method",
+			body:   `{"jsonrpc":"2.0","id":3,"method":"tasks/frobnicate"}`,
+			status: http.StatusOK,
+			want:   []string{"3:-32601"},
+		},
+		{
+			name:   "params not an object",
+			body:   `{"jsonrpc":"2.0","id":4,"method":"tasks/get","params":[1]}`,
+			status: http.StatusOK,
+			want:   []string{"4:-32602"},
+		},
+		{
+			name:   "notification",
+			body:   `{"jsonrpc":"2.0","method":"tasks/get","params":{"id":"missing"}}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "empty batch",
+			body:   `[]`,
+			status: http.StatusOK,
+			want:   []string{"null:-32600"},
+		},
+		{
+			name:   "oversized batch",
+			body:   "[" + strings.Join(tooMany, ",") + "]",
+			status: http.StatusOK,
+			want:   []string{"null:-32600"},
+		},
+		{
+			name:   "batch of notifications",
+			body:   `[{"jsonrpc":"2.0","method":"tasks/get","params":{"id":"a"}},{"jsonrpc":"2.0","method":"tasks/cancel","params":{"id":"b"}}]`,
+			status: http.StatusNoContent,
+		},
+		{
+			name: "mixed batch",
+			body: `[{"jsonrpc":"2.0","id":1,"method":"tasks/get","params":{"id":"a"}},
+				{"jsonrpc":"2.0","method":"tasks/get","params":{"id":"b"}},
+				1,
+				{"jsonrpc":"2.0","id":"x","method":"nope"}]`,
+			status: http.StatusOK,
+			want:   []string{"1:-32001", "null:-32600", `"x":-32601`},
+		},
+		{
+			name: "batch responses keep request order",
+			body: `[{"jsonrpc":"2.0","id":5,"method":"tasks/get","params":{"id":"a"}},
+				{"jsonrpc":"2.0","id":"b","method":"tasks/cancel","params":{"id":"b"}},
+				{"jsonrpc":"2.0","id":3,"method":"nope"},
+				{"jsonrpc":"2.0","id":2,"method":"tasks/get","params":{"id":"c"}},
+				{"jsonrpc":"2.0","id":1,"method":"tasks/get","params":{"id":"d"}}]`,
+			status: http.StatusOK,
+			want:   []string{"5:-32001", `"b":-32001`, "3:-32601", "2:-32001", "1:-32001"},
+		},
+		{
+			name: "streaming methods cannot be batched",
+			body: `[{"jsonrpc":"2.0","id":1,"method":"message/stream","params":{"message":{"role":"user","parts":[{"kind":"text","text":"hi"}]}}},
+				{"jsonrpc":"2.0","id":2,"method":"tasks/resubscribe","params":{"id":"a"}}]`,
+			status: http.StatusOK,
+			want:   []string{"1:-32600", "2:-32600"},
+		},
+	}
+
+	h := newTestHandler(t, config.A2AConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postA2A(h, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusNoContent {
+				if w.Body.Len() != 0 {
+					t.Fatalf("204 with body %q", w.Body.String())
+				}
+				return
+			}
+
+			var responses []rpcResponse
+			if strings.HasPrefix(strings.TrimSpace(tt.body), "[") && len(tt.want) > 1 {
+				if err := json.Unmarshal(w.Body.Bytes(), &responses); err != nil {
+					t.Fatalf("batch response %q: %v", w.Body.String(), err)
+				}
+			} else {
+				var resp rpcResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("response %q: %v", w.Body.String(), err)
+				}
+				responses = []rpcResponse{resp}
+			}
+
+			var got []string
+			for _, resp := range responses {
+				if resp.JSONRPC != "2.0" {
+					t.Errorf("jsonrpc = %q", resp.JSONRPC)
+				}
+				code := 0
+				if resp.Error != nil {
+					code = resp.Error.Code
+				}
+				id := string(resp.ID)
+				if id == "" {
+					id = "null"
+				}
+				got = append(got, fmt.Sprintf("%s:%d", id, code))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("responses = %v, want %v\nbody: %s", got, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestJSONRPCBodyLimit(t *testing.T) {
+	h := newTestHandler(t, config.A2AConfig{})
+	body := `{"jsonrpc":"2.0","id":1,"method":"tasks/get","params":{"id":"` + strings.Repeat("a", maxRequestBytes) + `"}}`
+	var resp rpcResponse
+	if err := json.Unmarshal(postA2A(h, body).Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != -32600 {
+		t.Errorf("error = %+v, want -32600", resp.Error)
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxBuckets is how many caller buckets are kept before full ones, which
+// hold nothing a fresh bucket would not, are dropped.
+const maxBuckets = 10000
+
+// skillLimits rate limits skills per caller with token buckets: each holds
+// up to a minute's worth of requests and refills continuously.
+type skillLimits struct {
+	mu        sync.Mutex
+	perMinute map[string]int
+	buckets   map[bucketKey]*bucket
+}
+
+type bucketKey struct {
+	skill, caller string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (l *skillLimits) set(skill string, perMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.perMinute == nil {
+		l.perMinute = make(map[string]int)
+		l.buckets = make(map[bucketKey]*bucket)
+	}
+	if perMinute <= 0 {
+		delete(l.perMinute, skill)
+	} else {
+		l.perMinute[skill] = perMinute
+	}
+	for key := range l.buckets {
+		if key.skill == skill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// take uses one of the caller's requests of the skill, or reports how long
+// until one is available.
+func (l *skillLimits) take(skill, caller string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	perMinute, ok := l.perMinute[skill]
+	if !ok {
+		return 0, true
+	}
+	now := time.Now()
+	key := bucketKey{skill, caller}
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.dropFull(now)
+		}
+		b = &bucket{tokens: float64(perMinute), last: now}
+		l.buckets[key] = b
+	}
+
+	rate := float64(perMinute) / time.Minute.Seconds()
+	b.tokens = math.Min(float64(perMinute), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// dropFull forgets buckets that have refilled since their last request.
+func (l *skillLimits) dropFull(now time.Time) {
+	for key, b := range l.buckets {
+		perMinute := float64(l.perMinute[key.skill])
+		if b.tokens+now.Sub(b.last).Minutes()*perMinute >= perMinute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// skill is the premium skill a message uses, if any.
+func (input messageInput) skill() string {
+	switch {
+	case input.resume != "":
+		return "resume_match"
+	case input.jobDescription != "":
+		return "similar_roles"
+	}
+	return ""
+}
+
+// checkRateLimit answers with errRateLimited when the caller is over the
+// limit of the message's skill.
+func (h *A2AHandler) checkRateLimit(w http.ResponseWriter, r *http.Request, id interface{}, input messageInput) bool {
+	skill, caller := input.skill(), callerID(r)
+	wait, ok := h.limits.take(skill, caller)
+	if ok {
+		return true
+	}
+	seconds := int(math.Ceil(wait.Seconds()))
+	h.logger.Warn().Str("skill", skill).Str("caller", caller).Int("retry_after", seconds).Msg("Skill rate limit reached")
+	h.sendError(w, id, errRateLimited, fmt.Sprintf("Rate limit for %s reached, retry in %ds", skill, seconds))
+	return false
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/config"
+)
+
+func TestSkillLimitsPerCaller(t *testing.T) {
+	var l skillLimits
+	l.set("resume_match", 2)
+
+	for i := range 2 {
+		if _, ok := l.take("resume_match", "a"); !ok {
+			t.Fatalf("request %d of caller a limited", i+1)
+		}
+	}
+	wait, ok := l.take("resume_match", "a")
+	if ok {
+		t.Fatal("third request of caller a allowed")
+	}
+	if wait <= 0 || wait > 30*time.Second {
+		t.Errorf("retry in %v, want up to 30s", wait)
+	}
+	if _, ok := l.take("resume_match", "b"); !ok {
+		t.Error("caller b limited by caller a's requests")
+	}
+	if _, ok := l.take("similar_roles", "a"); !ok {
+		t.Error("unlimited skill limited")
+	}
+
+	l.set("resume_match", 0)
+	if _, ok := l.take("resume_match", "a"); !ok {
+		t.Error("request limited after the limit was removed")
+	}
+}
+
+func TestSkillLimitsDropFull(t *testing.T) {
+	var l skillLimits
+	l.set("resume_match", 1)
+	l.take("resume_match", "idle")
+	l.take("resume_match", "busy")
+	l.buckets[bucketKey{"resume_match", "idle"}].last = time.Now().Add(-time.Minute)
+
+	l.dropFull(time.Now())
+	if _, ok := l.buckets[bucketKey{"resume_match", "idle"}]; ok {
+		t.Error("refilled bucket kept")
+	}
+	if _, ok := l.buckets[bucketKey{"resume_match", "busy"}]; !ok {
+		t.Error("empty bucket dropped")
+	}
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	h := newTestHandler(t, config.A2AConfig{PremiumRateLimit: 1})
+	input := messageInput{resume: "Go developer"}
+	check := func(remoteAddr, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = remoteAddr
+		if key != "" {
+			r.Header.Set("X-AGENT-API-KEY", key)
+		}
+		w := httptest.NewRecorder()
+		if h.checkRateLimit(w, r, 1, input) != (w.Body.Len() == 0) {
+			t.Fatalf("checkRateLimit result does not match the response %q", w.Body)
+		}
+		return w
+	}
+
+	if w := check("203.0.113.1:1234", ""); w.Body.Len() != 0 {
+		t.Fatalf("first request limited: %s", w.Body)
+	}
+	w := check("203.0.113.1:5678", "")
+	var resp rpcResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("second request from the same address: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != errRateLimited {
+		t.Fatalf("second request from the same address: got %s, want error %d", w.Body, errRateLimited)
+	}
+	if w := check("203.0.113.2:1234", ""); w.Body.Len() != 0 {
+		t.Errorf("other address limited: %s", w.Body)
+	}
+	if w := check("203.0.113.1:1234", "new-key-1"); w.Body.Len() == 0 {
+		t.Error("address allowed again by sending an API key")
+	}
+	if w := check("203.0.113.1:1234", "new-key-2"); w.Body.Len() == 0 {
+		t.Error("address allowed again by sending another API key")
+	}
+	if !h.checkRateLimit(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), 1, messageInput{}) {
+		t.Error("search without a premium skill limited")
+	}
+}
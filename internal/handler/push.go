@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/justinndidit/job-agent/internal/push"
+)
+
+// UsePushNotifications enables the tasks/pushNotificationConfig methods and
+// webhook delivery of finished tasks.
+func (h *A2AHandler) UsePushNotifications(n *push.Notifier) {
+	h.push = n
+}
+
+// notify sends a finished, canceled or input-required task to its webhooks.
+func (h *A2AHandler) notify(task *a2a.TaskResult) {
+	if h.push != nil {
+		h.push.Notify(task)
+	}
+}
+
+// checkPushConfig validates the webhook given with message/send or
+// message/stream, writing the error and reporting false if it cannot be
+// used.
+func (h *A2AHandler) checkPushConfig(w http.ResponseWriter, id interface{}, config *a2a.MessageConfiguration) bool {
+	if config == nil || config.PushNotificationConfig == nil {
+		return true
+	}
+	if h.push == nil {
+		h.sendError(w, id, errPushNotSupported, "Push Notification is not supported")
+		return false
+	}
+	if err := h.push.Validate(*config.PushNotificationConfig); err != nil {
+		h.sendError(w, id, -32602, "Invalid push notification config: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// registerPushConfig registers the webhook checked by checkPushConfig for
+// the task the message started.
+func (h *A2AHandler) registerPushConfig(taskID string, config *a2a.MessageConfiguration) {
+	if config == nil || config.PushNotificationConfig == nil {
+		return
+	}
+	if _, err := h.push.Set(taskID, *config.PushNotificationConfig); err != nil {
+		h.logger.Warn().Err(err).Str("task_id", taskID).Msg("Push notification config not registered")
+	}
+}
+
+// handlePushConfigSet registers a webhook for a task
+// (tasks/pushNotificationConfig/set).
+func (h *A2AHandler) handlePushConfigSet(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	taskID := req.Params.TaskID
+	if h.push == nil {
+		h.sendError(w, req.ID, errPushNotSupported, "Push Notification is not supported")
+		return
+	}
+	if req.Params.PushNotificationConfig == nil {
+		h.sendError(w, req.ID, -32602, "pushNotificationConfig is required")
+		return
+	}
+	if _, ok := h.callerTask(r, taskID); !ok {
+		h.sendError(w, req.ID, errTaskNotFound, "Task not found")
+		return
+	}
+
+	config, err := h.push.Set(taskID, *req.Params.PushNotificationConfig)
+	if err != nil {
+		h.sendError(w, req.ID, -32602, "Invalid push notification config: "+err.Error())
+		return
+	}
+	h.sendResult(w, req.ID, a2a.TaskPushNotificationConfig{TaskID: taskID, PushNotificationConfig: config})
+}
+
+// handlePushConfigGet returns one of a task's webhooks
+// (tasks/pushNotificationConfig/get).
+func (h *A2AHandler) handlePushConfigGet(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	if !h.pushTaskExists(w, r, req) {
+		return
+	}
+	config, ok := h.push.Get(req.Params.ID, req.Params.PushNotificationConfigID)
+	if !ok {
+		h.sendError(w, req.ID, -32602, "Push notification config not found")
+		return
+	}
+	h.sendResult(w, req.ID, a2a.TaskPushNotificationConfig{TaskID: req.Params.ID, PushNotificationConfig: config})
+}
+
+// handlePushConfigList returns all of a task's webhooks
+// (tasks/pushNotificationConfig/list).
+func (h *A2AHandler) handlePushConfigList(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	if !h.pushTaskExists(w, r, req) {
+		return
+	}
+	result := []a2a.TaskPushNotificationConfig{}
+	for _, config := range h.push.List(req.Params.ID) {
+		result = append(result, a2a.TaskPushNotificationConfig{TaskID: req.Params.ID, PushNotificationConfig: config})
+	}
+	h.sendResult(w, req.ID, result)
+}
+
+// handlePushConfigDelete removes a task's webhook
+// (tasks/pushNotificationConfig/delete).
+func (h *A2AHandler) handlePushConfigDelete(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	if !h.pushTaskExists(w, r, req) {
+		return
+	}
+	if !h.push.Delete(req.Params.ID, req.Params.PushNotificationConfigID) {
+		h.sendError(w, req.ID, -32602, "Push notification config not found")
+		return
+	}
+	// The spec's result is null, which omitempty would drop
+	h.sendResult(w, req.ID, json.RawMessage("null"))
+}
+
+func (h *A2AHandler) pushTaskExists(w http.ResponseWriter, r *http.Request, req *A2ARequest) bool {
+	if h.push == nil {
+		h.sendError(w, req.ID, errPushNotSupported, "Push Notification is not supported")
+		return false
+	}
+	if _, ok := h.callerTask(r, req.Params.ID); !ok {
+		h.sendError(w, req.ID, errTaskNotFound, "Task not found")
+		return false
+	}
+	return true
+}
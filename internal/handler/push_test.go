@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/justinndidit/job-agent/internal/push"
+	"github.com/rs/zerolog"
+)
+
+func newTestNotifier(t *testing.T, allowPrivate bool) *push.Notifier {
+	t.Helper()
+	log := zerolog.Nop()
+	n := push.NewNotifier(1, 1, time.Second, "", allowPrivate, &log)
+	t.Cleanup(func() { n.Close(context.Background()) })
+	return n
+}
+
+func TestMessagePushConfigChecked(t *testing.T) {
+	const message = `{"jsonrpc": "2.0", "id": 1, "method": %q, "params": {
+		"message": {"kind": "message", "role": "user", "messageId": "m1", "parts": [{"kind": "text", "text": "go jobs in Berlin"}]},
+		"configuration": {"pushNotificationConfig": {"url": "http://127.0.0.1/hook"}}}}`
+
+	for _, method := range []string{"message/send", "message/stream"} {
+		t.Run(method, func(t *testing.T) {
+			body := fmt.Sprintf(message, method)
+
+			h := newTestHandler(t, config.A2AConfig{})
+			if code := rpcErrorCode(t, postA2A(h, body).Body.Bytes()); code != errPushNotSupported {
+				t.Errorf("without push notifications: error %d, want %d", code, errPushNotSupported)
+			}
+
+			h.UsePushNotifications(newTestNotifier(t, false))
+			if code := rpcErrorCode(t, postA2A(h, body).Body.Bytes()); code != -32602 {
+				t.Errorf("private webhook url: error %d, want -32602", code)
+			}
+		})
+	}
+}
+
+func TestRegisterPushConfig(t *testing.T) {
+	h := newTestHandler(t, config.A2AConfig{})
+	n := newTestNotifier(t, true)
+	h.UsePushNotifications(n)
+
+	h.registerPushConfig("task-1", nil)
+	h.registerPushConfig("task-1", &a2a.MessageConfiguration{})
+	if configs := n.List("task-1"); len(configs) != 0 {
+		t.Fatalf("registered %v without a push config", configs)
+	}
+
+	h.registerPushConfig("task-1", &a2a.MessageConfiguration{
+		PushNotificationConfig: &a2a.PushNotificationConfig{Url: "http://127.0.0.1/hook", Token: "t"},
+	})
+	config, ok := n.Get("task-1", "")
+	if !ok || config.Url != "http://127.0.0.1/hook" || config.ID != "task-1" {
+		t.Errorf("registered %+v, %v", config, ok)
+	}
+}
+
+func rpcErrorCode(t *testing.T, body []byte) int {
+	t.Helper()
+	var resp rpcResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("response %s: %v", body, err)
+	}
+	if resp.Error == nil {
+		t.Fatalf("response %s has no error", body)
+	}
+	return resp.Error.Code
+}
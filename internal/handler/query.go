@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/justinndidit/job-agent/internal/scraper"
+)
+
+// JobQuerySchemaPath is where the schema of the structured query data part
+// is served.
+const JobQuerySchemaPath = "/schemas/job-query.json"
+
+//go:embed schemas/job-query.json
+var jobQuerySchema []byte
+
+// queryData is a structured job search sent as a data part, described by
+// schemas/job-query.json.
+type queryData struct {
+	Title                string   `json:"title"`
+	Titles               []string `json:"titles,omitempty"`
+	Location             string   `json:"location,omitempty"`
+	Remote               *bool    `json:"remote,omitempty"`
+	ExcludeOrganizations []string `json:"excludeOrganizations,omitempty"`
+	Language             string   `json:"language,omitempty"`
+	Page                 *int     `json:"page,omitempty"`
+}
+
+// JobQuerySchema serves the JSON schema of the structured query data part
+// (GET /schemas/job-query.json)
+func (h *A2AHandler) JobQuerySchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(jobQuerySchema)
+}
+
+// parseQueryData decodes a data part into a query. Unknown fields are an
+// error, so a typo cannot silently widen the search.
+func parseQueryData(data json.RawMessage) (scraper.JobQuery, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var q queryData
+	if err := dec.Decode(&q); err != nil {
+		return scraper.JobQuery{}, err
+	}
+	page := 1
+	if q.Page != nil {
+		page = *q.Page
+	}
+	return agent.CleanQuery(scraper.JobQuery{
+		Title:                q.Title,
+		Titles:               q.Titles,
+		Location:             q.Location,
+		Remote:               q.Remote,
+		ExcludeOrganizations: q.ExcludeOrganizations,
+		Language:             q.Language,
+		Page:                 page,
+	})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/justinndidit/job-agent/internal/agent"
+)
+
+func TestParseQueryDataPage(t *testing.T) {
+	tests := []struct {
+		data    string
+		page    int
+		invalid bool
+	}{
+		{data: `{"title": "backend engineer"}`, page: 1},
+		{data: `{"title": "backend engineer", "page": 1}`, page: 1},
+		{data: `{"title": "backend engineer", "page": 10}`, page: 10},
+		{data: `{"title": "backend engineer", "page": 0}`, invalid: true},
+		{data: `{"title": "backend engineer", "page": -1}`, invalid: true},
+		{data: `{"title": "backend engineer", "page": 11}`, invalid: true},
+	}
+	for _, tt := range tests {
+		q, err := parseQueryData([]byte(tt.data))
+		switch {
+		case tt.invalid:
+			if !errors.Is(err, agent.ErrInvalidQuery) {
+				t.Errorf("%s: error %v, want %v", tt.data, err, agent.ErrInvalidQuery)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.data, err)
+		case q.Page != tt.page:
+			t.Errorf("%s: page %d, want %d", tt.data, q.Page, tt.page)
+		}
+	}
+}
+
+// TestQuerySchemaAgreesWithCleanQuery checks documents at the schema's
+// limits: parseQueryData must accept exactly those the schema allows.
+func TestQuerySchemaAgreesWithCleanQuery(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(jobQuerySchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	titles := func(n int) string {
+		quoted := make([]string, n)
+		for i := range quoted {
+			quoted[i] = fmt.Sprintf("%q", fmt.Sprintf("title %d", i))
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	docs := []string{
+		`{"title": "backend engineer", "titles": ` + titles(5) + `}`,
+		`{"title": "backend engineer", "titles": ` + titles(6) + `}`,
+		`{"title": "` + strings.Repeat("a", 80) + `"}`,
+		`{"title": "` + strings.Repeat("a", 81) + `"}`,
+		`{"title": "backend engineer", "excludeOrganizations": ` + titles(20) + `}`,
+		`{"title": "backend engineer", "excludeOrganizations": ` + titles(21) + `}`,
+		`{"title": "backend engineer", "page": 10}`,
+		`{"title": "backend engineer", "page": 11}`,
+	}
+	for _, doc := range docs {
+		var v any
+		if err := json.Unmarshal([]byte(doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		schemaErrs := validate(schema, schema, v, "query")
+		_, err := parseQueryData([]byte(doc))
+		if (len(schemaErrs) == 0) != (err == nil) {
+			t.Errorf("%.60s...: schema errors %v, parseQueryData error %v", doc, schemaErrs, err)
+		}
+	}
+}
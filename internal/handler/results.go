@@ -0,0 +1,95 @@
+package handler
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/justinndidit/job-agent/internal/scraper"
+)
+
+// JobResultsSchemaPath is where the schema of the job-results data part is
+// served.
+const JobResultsSchemaPath = "/schemas/job-results.json"
+
+//go:embed schemas/job-results.json
+var jobResultsSchema []byte
+
+// jobResults is the data part of the job-results artifact, described by
+// schemas/job-results.json.
+type jobResults struct {
+	Query *scraper.JobQuery    `json:"query,omitempty"`
+	Count int                  `json:"count"`
+	Jobs  []scraper.JobPosting `json:"jobs"`
+}
+
+// JobResultsSchema serves the JSON schema of the job-results data part
+// (GET /schemas/job-results.json)
+func (h *A2AHandler) JobResultsSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(jobResultsSchema)
+}
+
+// jobResultsArtifact carries search results as readable text and as
+// structured data, each only if the client accepts it.
+func jobResultsArtifact(text string, result *agent.SearchResult, outputModes []string) a2a.Artifact {
+	artifact := a2a.Artifact{ArtifactID: generateID("artifact"), Name: "job-results"}
+	if accepts(outputModes, "text/plain") {
+		artifact.Parts = append(artifact.Parts, a2a.MessagePart{
+			Kind:     "text",
+			Text:     text,
+			Metadata: map[string]interface{}{"mimeType": "text/plain"},
+		})
+	}
+	if accepts(outputModes, "application/json") {
+		query := result.Query
+		artifact.Parts = append(artifact.Parts, jobsDataPart(&query, result.Jobs))
+	}
+	return artifact
+}
+
+func jobsDataPart(query *scraper.JobQuery, jobs []scraper.JobPosting) a2a.MessagePart {
+	if jobs == nil {
+		jobs = []scraper.JobPosting{}
+	}
+	return a2a.MessagePart{
+		Kind:     "data",
+		Data:     jobResults{Query: query, Count: len(jobs), Jobs: jobs},
+		Metadata: map[string]interface{}{"mimeType": "application/json", "schema": JobResultsSchemaPath},
+	}
+}
+
+// accepts reports whether mime is one of the accepted output modes, which
+// may use wildcards like "text/*". No modes means anything goes.
+func accepts(modes []string, mime string) bool {
+	if len(modes) == 0 {
+		return true
+	}
+	for _, mode := range modes {
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		if mode == mime || mode == "*/*" || (strings.HasSuffix(mode, "/*") && strings.HasPrefix(mime, strings.TrimSuffix(mode, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsAny(modes []string, mimes ...string) bool {
+	for _, mime := range mimes {
+		if accepts(modes, mime) {
+			return true
+		}
+	}
+	return false
+}
+
+// markdownMode is the type to label markdown with: clients that only take
+// plain text get it as such.
+func markdownMode(modes []string) string {
+	if accepts(modes, "text/markdown") {
+		return "text/markdown"
+	}
+	return "text/plain"
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/agent"
+	"github.com/justinndidit/job-agent/internal/i18n"
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+)
+
+// eventBuffer is how many events a slow stream can fall behind before
+// events are dropped. The final status is always sent.
+const eventBuffer = 64
+
+// handleMessageStream runs a message/send request and streams the task, its
+// status updates and its artifacts as Server-Sent Events (message/stream).
+func (h *A2AHandler) handleMessageStream(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	input, rpcErr := h.parseInput(r.Context(), req.Params)
+	if rpcErr != nil {
+		h.sendError(w, req.ID, rpcErr.Code, rpcErr.Message)
+		return
+	}
+	if !h.checkPushConfig(w, req.ID, req.Params.Configuration) || !h.checkRateLimit(w, r, req.ID, input) {
+		return
+	}
+
+	task, pending, rpcErr := h.beginTask(r, req)
+	if rpcErr != nil {
+		h.sendError(w, req.ID, rpcErr.Code, rpcErr.Message)
+		return
+	}
+	h.registerPushConfig(task.ID, req.Params.Configuration)
+	events, unsubscribe := h.events.subscribe(task.ID)
+	defer unsubscribe()
+
+	stream := h.startStream(w, req.ID)
+	stream.send(task)
+	// The task outlives the connection; clients that drop can pick it up
+	// again with tasks/resubscribe
+	go h.runTask(context.WithoutCancel(usageContext(r, task.ContextID)), req, task, input, pending)
+	h.relay(r.Context(), stream, task.ID, events)
+}
+
+// handleTaskResubscribe streams the rest of a running task's events,
+// starting with the task as it is now (tasks/resubscribe).
+func (h *A2AHandler) handleTaskResubscribe(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	events, unsubscribe := h.events.subscribe(req.Params.ID)
+	defer unsubscribe()
+
+	task, ok := h.callerTask(r, req.Params.ID)
+	if !ok {
+		h.sendError(w, req.ID, errTaskNotFound, "Task not found")
+		return
+	}
+
+	stream := h.startStream(w, req.ID)
+	stream.send(task)
+	if isTerminal(task.Status.State) || task.Status.State == TaskStateInputRequired {
+		return
+	}
+	h.relay(r.Context(), stream, task.ID, events)
+}
+
+// relay writes a task's events to the stream until the final one, or until
+// the client goes away.
+func (h *A2AHandler) relay(ctx context.Context, stream *eventStream, taskID string, events <-chan interface{}) {
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// The final event did not fit in the buffer
+				if task, found := h.tasks.Get(taskID); found {
+					stream.send(statusEvent(task, true))
+				}
+				return
+			}
+			if err := stream.send(event); err != nil {
+				h.logger.Debug().Err(err).Str("task_id", taskID).Msg("Stream closed by client")
+				return
+			}
+			if status, ok := event.(a2a.TaskStatusUpdateEvent); ok && status.Final {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// progress turns the executor's progress reports into status updates, and
+// postings as they arrive into appends to a "jobs-found" artifact.
+func (h *A2AHandler) progress(task *a2a.TaskResult, lang string, outputModes []string) func(agent.Progress) {
+	artifactID := generateID("artifact")
+	var mu sync.Mutex
+	found := 0
+	return func(p agent.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var text string
+		switch p.Stage {
+		case agent.StageFound:
+			if len(p.Jobs) == 0 {
+				return
+			}
+			if accepts(outputModes, "application/json") {
+				h.events.publish(task.ID, a2a.TaskArtifactUpdateEvent{
+					TaskID:    task.ID,
+					ContextID: task.ContextID,
+					Kind:      "artifact-update",
+					Artifact: a2a.Artifact{
+						ArtifactID: artifactID,
+						Name:       "jobs-found",
+						Parts:      []a2a.MessagePart{jobsDataPart(nil, p.Jobs)},
+					},
+					Append: found > 0,
+				}, false)
+			}
+			found += len(p.Jobs)
+			text = i18n.T(lang, "progress_found", found)
+		default:
+			text = i18n.T(lang, "progress_"+p.Stage)
+		}
+		message := agentMessage(text, nil)
+		h.setTaskState(task.ID, TaskStateWorking, &message, map[string]interface{}{"stage": p.Stage})
+	}
+}
+
+// eventStream writes JSON-RPC responses as Server-Sent Events.
+type eventStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+	id interface{}
+}
+
+func (h *A2AHandler) startStream(w http.ResponseWriter, id interface{}) *eventStream {
+	rc := http.NewResponseController(w)
+	// Searches can take longer than the server's write timeout
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	return &eventStream{w: w, rc: rc, id: id}
+}
+
+func (s *eventStream) send(result interface{}) error {
+	data, err := json.Marshal(A2AResponse{JSONRPC: "2.0", Result: result, ID: s.id})
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// taskEvents fans task events out to the streams following each task.
+type taskEvents struct {
+	mu   sync.Mutex
+	subs map[string]map[chan interface{}]struct{}
+}
+
+func (e *taskEvents) subscribe(taskID string) (<-chan interface{}, func()) {
+	ch := make(chan interface{}, eventBuffer)
+	e.mu.Lock()
+	if e.subs[taskID] == nil {
+		e.subs[taskID] = make(map[chan interface{}]struct{})
+	}
+	e.subs[taskID][ch] = struct{}{}
+	e.mu.Unlock()
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if subs, ok := e.subs[taskID]; ok {
+			delete(subs, ch)
+			if len(subs) == 0 {
+				delete(e.subs, taskID)
+			}
+		}
+	}
+}
+
+// publish hands event to every subscriber of the task without blocking,
+// dropping it for subscribers that are too far behind. A final event closes
+// the subscriptions.
+func (e *taskEvents) publish(taskID string, event interface{}, final bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.subs[taskID] {
+		select {
+		case ch <- event:
+		default:
+		}
+		if final {
+			close(ch)
+		}
+	}
+	if final {
+		delete(e.subs, taskID)
+	}
+}
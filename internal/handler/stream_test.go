@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+)
+
+func TestStreamOutlivesRequestTimeout(t *testing.T) {
+	const caller = "203.0.113.1"
+	h := newTestHandler(t, config.A2AConfig{})
+	h.timeout = 10 * time.Millisecond
+	task := &a2a.TaskResult{
+		ID: "task_1", ContextID: "ctx_1", Kind: "task", Owner: caller,
+		Status: a2a.Task{ID: "task_1", State: TaskStateWorking},
+	}
+	h.tasks.Set(task)
+
+	done := make(chan string)
+	go func() {
+		w := postA2AFrom(h, caller+":1234", `{"jsonrpc": "2.0", "id": 1, "method": "tasks/resubscribe", "params": {"id": "task_1"}}`)
+		done <- w.Body.String()
+	}()
+
+	subscribed := func() bool {
+		h.events.mu.Lock()
+		defer h.events.mu.Unlock()
+		return len(h.events.subs["task_1"]) > 0
+	}
+	for !subscribed() {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(5 * h.timeout)
+	select {
+	case body := <-done:
+		t.Fatalf("stream ended with the request timeout: %s", body)
+	default:
+	}
+
+	completed := *task
+	completed.Status.State = TaskStateCompleted
+	h.events.publish("task_1", statusEvent(&completed, true), true)
+	select {
+	case body := <-done:
+		if n := strings.Count(body, "data: "); n != 2 || !strings.Contains(body, `"final":true`) {
+			t.Errorf("got %d events, want the task and its final status:\n%s", n, body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream did not end on the final event")
+	}
+}
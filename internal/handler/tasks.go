@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/justinndidit/job-agent/internal/scraper"
+)
+
+// A2A task states
+const (
+	TaskStateSubmitted     = "submitted"
+	TaskStateWorking       = "working"
+	TaskStateInputRequired = "input-required"
+	TaskStateCompleted     = "completed"
+	TaskStateFailed        = "failed"
+	TaskStateCanceled      = "canceled"
+	TaskStateRejected      = "rejected"
+)
+
+// A2A task errors
+const (
+	errTaskNotFound      = -32001
+	errTaskNotCancelable = -32002
+	errPushNotSupported  = -32003
+	// errContentTypeNotSupported: the client accepts none of the output modes
+	errContentTypeNotSupported   = -32005
+	errExtendedCardNotConfigured = -32007
+	// errRateLimited is not an A2A code: a premium skill is over its limit
+	errRateLimited = -32029
+)
+
+func isTerminal(state string) bool {
+	switch state {
+	case TaskStateCompleted, TaskStateFailed, TaskStateCanceled, TaskStateRejected:
+		return true
+	}
+	return false
+}
+
+// beginTask creates the task for a message/send request in the submitted
+// state, or continues the task it names if that task is waiting for input,
+// returning the query left pending by the clarifying question. The message's
+// taskId and contextId are filled in. Naming a task that does not exist, or
+// is another caller's, fails with errTaskNotFound.
+func (h *A2AHandler) beginTask(r *http.Request, req *A2ARequest) (*a2a.TaskResult, scraper.JobQuery, *A2AError) {
+	msg := &req.Params.Message
+	if msg.ContextID == "" {
+		msg.ContextID = generateID("ctx")
+	}
+
+	var task *a2a.TaskResult
+	var pending scraper.JobQuery
+	if msg.TaskID != "" {
+		prev, ok := h.callerTask(r, msg.TaskID)
+		if !ok {
+			return nil, pending, &A2AError{Code: errTaskNotFound, Message: "Task not found"}
+		}
+		if prev.Status.State == TaskStateInputRequired {
+			task = prev
+			if q, ok := prev.Pending.(scraper.JobQuery); ok {
+				pending = q
+			}
+			msg.ContextID = prev.ContextID
+		} else {
+			// Finished tasks are immutable; follow-ups start a new task in
+			// the same context
+			h.logger.Info().Str("task_id", prev.ID).Str("state", prev.Status.State).Msg("Task already finished, starting a new one")
+			msg.ReferenceTaskIDs = append(msg.ReferenceTaskIDs, prev.ID)
+		}
+	}
+	if task == nil {
+		msg.TaskID = generateID("task")
+		task = &a2a.TaskResult{ID: msg.TaskID, ContextID: msg.ContextID, Artifacts: []a2a.Artifact{}, Kind: "task", Owner: callerID(r)}
+	}
+
+	task.History = append(task.History, toA2AMessage(*msg))
+	task.Status = a2a.Task{ID: task.ID, State: TaskStateSubmitted, Timestamp: now()}
+	h.tasks.Set(task)
+	return task, pending, nil
+}
+
+// callerTask returns the task with the given id if r's caller created it.
+// Other callers' tasks are reported missing, not forbidden, so their ids
+// cannot be probed.
+func (h *A2AHandler) callerTask(r *http.Request, id string) (*a2a.TaskResult, bool) {
+	task, ok := h.tasks.Get(id)
+	if !ok || task.Owner != callerID(r) {
+		return nil, false
+	}
+	return task, true
+}
+
+// setTaskState moves a running task to state, with an optional progress
+// message, and tells its subscribers.
+func (h *A2AHandler) setTaskState(taskID, state string, message *Message, metadata map[string]interface{}) *a2a.TaskResult {
+	task, ok := h.tasks.Update(taskID, func(t *a2a.TaskResult) {
+		if isTerminal(t.Status.State) {
+			return
+		}
+		t.Status = a2a.Task{ID: t.ID, State: state, Timestamp: now(), Metadata: metadata}
+		if message != nil {
+			message.TaskID, message.ContextID = t.ID, t.ContextID
+			status := toA2AMessage(*message)
+			t.Status.Message = &status
+		}
+	})
+	if ok && !isTerminal(task.Status.State) {
+		h.events.publish(task.ID, statusEvent(task, false), false)
+	}
+	return task
+}
+
+// finishTask records the outcome: the reply joins the history and becomes
+// the status message. A task canceled in the meantime stays canceled.
+func (h *A2AHandler) finishTask(taskID string, outcome taskOutcome) *a2a.TaskResult {
+	canceled := false
+	task, ok := h.tasks.Update(taskID, func(t *a2a.TaskResult) {
+		if canceled = t.Status.State == TaskStateCanceled; canceled {
+			return
+		}
+		outcome.reply.TaskID, outcome.reply.ContextID = t.ID, t.ContextID
+		reply := toA2AMessage(outcome.reply)
+		t.History = append(t.History, reply)
+		t.Status = a2a.Task{ID: t.ID, State: outcome.state, Message: &reply, Timestamp: now(), Metadata: outcome.metadata}
+		t.Pending = nil
+		if outcome.pending != nil {
+			t.Pending = *outcome.pending
+		}
+		if outcome.artifacts != nil {
+			t.Artifacts = outcome.artifacts
+		}
+	})
+	if !ok {
+		// Idle for longer than the store keeps unfinished tasks; the client
+		// still gets the outcome, but the task cannot be fetched again
+		h.logger.Warn().Str("task_id", taskID).Msg("Task expired before it finished")
+		outcome.reply.TaskID = taskID
+		reply := toA2AMessage(outcome.reply)
+		task = &a2a.TaskResult{
+			ID: taskID, Kind: "task", Artifacts: outcome.artifacts, History: []a2a.A2AMessage{reply},
+			Status: a2a.Task{ID: taskID, State: outcome.state, Message: &reply, Timestamp: now(), Metadata: outcome.metadata},
+		}
+	}
+	if !canceled {
+		for _, artifact := range outcome.artifacts {
+			h.events.publish(task.ID, a2a.TaskArtifactUpdateEvent{
+				TaskID: task.ID, ContextID: task.ContextID, Kind: "artifact-update", Artifact: artifact, LastChunk: true,
+			}, false)
+		}
+		h.events.publish(task.ID, statusEvent(task, true), true)
+		h.notify(task)
+	}
+	if isTerminal(task.Status.State) {
+		h.tasks.Finish(task.ID)
+	}
+	return task
+}
+
+// forgetTask drops what is kept about a task besides the task itself, once
+// the store has evicted it.
+func (h *A2AHandler) forgetTask(taskID string) {
+	if h.push != nil {
+		h.push.Forget(taskID)
+	}
+}
+
+// statusEvent reports a task's current status. The input-required state
+// ends a stream just like the terminal states.
+func statusEvent(task *a2a.TaskResult, final bool) a2a.TaskStatusUpdateEvent {
+	return a2a.TaskStatusUpdateEvent{TaskID: task.ID, ContextID: task.ContextID, Kind: "status-update", Status: task.Status, Final: final}
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// handleTaskGet returns a task, with only the last historyLength messages of
+// its history if that is set (tasks/get).
+func (h *A2AHandler) handleTaskGet(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	if req.Params.HistoryLength != nil && *req.Params.HistoryLength < 0 {
+		h.sendError(w, req.ID, -32602, "historyLength must not be negative")
+		return
+	}
+	task, ok := h.callerTask(r, req.Params.ID)
+	if !ok {
+		h.sendError(w, req.ID, errTaskNotFound, "Task not found")
+		return
+	}
+	if n := req.Params.HistoryLength; n != nil && *n < len(task.History) {
+		task.History = task.History[len(task.History)-*n:]
+	}
+	h.sendResult(w, req.ID, task)
+}
+
+// handleTaskCancel marks a task canceled and stops its in-flight search
+// (tasks/cancel). Finished tasks cannot be canceled.
+func (h *A2AHandler) handleTaskCancel(w http.ResponseWriter, r *http.Request, req *A2ARequest) {
+	if _, ok := h.callerTask(r, req.Params.ID); !ok {
+		h.sendError(w, req.ID, errTaskNotFound, "Task not found")
+		return
+	}
+	var state string
+	task, ok := h.tasks.Update(req.Params.ID, func(t *a2a.TaskResult) {
+		state = t.Status.State
+		if !isTerminal(state) {
+			t.Status = a2a.Task{ID: t.ID, State: TaskStateCanceled, Timestamp: now()}
+		}
+	})
+	if !ok {
+		h.sendError(w, req.ID, errTaskNotFound, "Task not found")
+		return
+	}
+	if isTerminal(state) {
+		h.sendError(w, req.ID, errTaskNotCancelable, "Task cannot be canceled in state "+state)
+		return
+	}
+
+	h.running.cancel(task.ID)
+	h.events.publish(task.ID, statusEvent(task, true), true)
+	h.notify(task)
+	h.tasks.Finish(task.ID)
+	h.logger.Info().Str("task_id", task.ID).Str("previous_state", state).Msg("Task canceled")
+	h.sendResult(w, req.ID, task)
+}
+
+// runningTasks holds the cancel func of every task being worked on, so
+// tasks/cancel can stop its model and scraper calls.
+type runningTasks struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// start derives the context a task runs under. done must be called when the
+// task finishes.
+func (rt *runningTasks) start(parent context.Context, taskID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	rt.mu.Lock()
+	rt.cancels[taskID] = cancel
+	rt.mu.Unlock()
+	return ctx, func() {
+		rt.mu.Lock()
+		delete(rt.cancels, taskID)
+		rt.mu.Unlock()
+		cancel()
+	}
+}
+
+func (rt *runningTasks) cancel(taskID string) {
+	rt.mu.Lock()
+	cancel, ok := rt.cancels[taskID]
+	rt.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
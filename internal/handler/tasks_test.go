@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/justinndidit/job-agent/internal/scraper"
+)
+
+func postA2AFrom(h *A2AHandler, remoteAddr, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	h.HandleA2A(w, r)
+	return w
+}
+
+func TestTasksOnlyVisibleToTheirOwner(t *testing.T) {
+	const owner, other = "203.0.113.1:1234", "203.0.113.2:1234"
+	h := newTestHandler(t, config.A2AConfig{})
+	n := newTestNotifier(t, true)
+	h.UsePushNotifications(n)
+	h.tasks.Set(&a2a.TaskResult{
+		ID: "task_1", ContextID: "ctx_1", Kind: "task", Owner: "203.0.113.1",
+		Status: a2a.Task{ID: "task_1", State: TaskStateWorking},
+	})
+
+	requests := []struct{ method, params string }{
+		{"tasks/get", `{"id": "task_1"}`},
+		{"tasks/pushNotificationConfig/set", `{"taskId": "task_1", "pushNotificationConfig": {"url": "http://127.0.0.1/hook"}}`},
+		{"tasks/pushNotificationConfig/get", `{"id": "task_1"}`},
+		{"tasks/pushNotificationConfig/list", `{"id": "task_1"}`},
+		{"tasks/pushNotificationConfig/delete", `{"id": "task_1", "pushNotificationConfigId": "task_1"}`},
+		{"tasks/resubscribe", `{"id": "task_1"}`},
+		{"message/send", `{"message": {"kind": "message", "role": "user", "messageId": "m1", "taskId": "task_1", "parts": [{"kind": "text", "text": "hi"}]}}`},
+		{"tasks/cancel", `{"id": "task_1"}`},
+	}
+	for _, req := range requests {
+		body := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": %q, "params": %s}`, req.method, req.params)
+		if code := rpcErrorCode(t, postA2AFrom(h, other, body).Body.Bytes()); code != errTaskNotFound {
+			t.Errorf("%s by another caller: error %d, want %d", req.method, code, errTaskNotFound)
+		}
+	}
+	if configs := n.List("task_1"); len(configs) != 0 {
+		t.Errorf("another caller registered %v", configs)
+	}
+	if task, _ := h.tasks.Get("task_1"); task.Status.State != TaskStateWorking {
+		t.Errorf("another caller moved the task to %s", task.Status.State)
+	}
+
+	for _, req := range requests[:2] {
+		body := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": %q, "params": %s}`, req.method, req.params)
+		var resp rpcResponse
+		if err := json.Unmarshal(postA2AFrom(h, owner, body).Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s by the owner: %v", req.method, err)
+		}
+		if resp.Error != nil {
+			t.Errorf("%s by the owner: %s", req.method, resp.Error.Message)
+		}
+	}
+}
+
+func TestGenerateID(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 1000 {
+		id := generateID("task")
+		if !strings.HasPrefix(id, "task_") || len(id) < len("task_")+20 {
+			t.Fatalf("id %q is not a prefixed random id", id)
+		}
+		if seen[id] {
+			t.Fatalf("id %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestBeginTask(t *testing.T) {
+	h := newTestHandler(t, config.A2AConfig{})
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	pending := scraper.JobQuery{Title: "Backend Engineer"}
+	h.tasks.Set(&a2a.TaskResult{
+		ID: "task_waiting", ContextID: "ctx_1", Kind: "task", Owner: callerID(r), Pending: pending,
+		Status: a2a.Task{ID: "task_waiting", State: TaskStateInputRequired},
+	})
+	h.tasks.Set(&a2a.TaskResult{
+		ID: "task_done", ContextID: "ctx_1", Kind: "task", Owner: callerID(r),
+		Status: a2a.Task{ID: "task_done", State: TaskStateCompleted},
+	})
+	begin := func(taskID string) (*a2a.TaskResult, scraper.JobQuery, *A2AError) {
+		req := &A2ARequest{Params: A2AParams{Message: Message{Role: "user", TaskID: taskID, ContextID: "ctx_1", Parts: []Part{{Kind: "text", Text: "in Berlin"}}}}}
+		return h.beginTask(r, req)
+	}
+
+	if _, _, rpcErr := begin("task_made_up"); rpcErr == nil || rpcErr.Code != errTaskNotFound {
+		t.Errorf("unknown task id: error %v, want %d", rpcErr, errTaskNotFound)
+	}
+	if _, ok := h.tasks.Get("task_made_up"); ok {
+		t.Error("task created under a client-chosen id")
+	}
+
+	task, got, rpcErr := begin("task_waiting")
+	if rpcErr != nil || task.ID != "task_waiting" || got.Title != pending.Title {
+		t.Errorf("continuing an input-required task: %v, %+v, %v", task, got, rpcErr)
+	}
+	body, _ := json.Marshal(task)
+	if strings.Contains(string(body), "Backend Engineer") {
+		t.Errorf("pending query sent to the client: %s", body)
+	}
+
+	task, _, rpcErr = begin("task_done")
+	if rpcErr != nil || task.ID == "task_done" || task.ContextID != "ctx_1" {
+		t.Errorf("reply to a finished task: %v, %v", task, rpcErr)
+	}
+}
+
+func TestFailedOutcomeHidesCause(t *testing.T) {
+	outcome := failedOutcome("en")
+	if outcome.state != TaskStateFailed || outcome.metadata["error"] != "request_failed" {
+		t.Errorf("outcome %+v", outcome)
+	}
+}
@@ -0,0 +1,114 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const Default = "en"
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[string]map[string]string {
+	entries, err := catalogFS.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+
+	out := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := catalogFS.ReadFile(path.Join("catalogs", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", entry.Name(), err))
+		}
+		out[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return out
+}
+
+// Normalize maps tags like "fr-CA" or "PT" onto a supported catalog, falling
+// back to English.
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return Default
+}
+
+// T formats the message key in lang, using the English text for keys a
+// catalog is missing.
+func T(lang, key string, args ...any) string {
+	msg, ok := catalogs[Normalize(lang)][key]
+	if !ok {
+		if msg, ok = catalogs[Default][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+var names = map[string]string{"en": "English", "fr": "French", "es": "Spanish", "pt": "Portuguese"}
+
+// Name returns the English name of lang, for use in prompts.
+func Name(lang string) string {
+	return names[Normalize(lang)]
+}
+
+var markers = map[string][]string{
+	"fr": {"emploi", "emplois", "poste", "postes", "cherche", "recherche", "offres", "travail", "je", "à", "dans", "des", "les", "pour", "développeur", "ingénieur", "télétravail", "bonjour", "merci", "seulement", "plutôt"},
+	"es": {"empleo", "empleos", "trabajo", "trabajos", "busco", "buscando", "puesto", "puestos", "ofertas", "en", "los", "las", "para", "desarrollador", "ingeniero", "hola", "gracias", "solo", "remotos"},
+	"pt": {"emprego", "empregos", "vaga", "vagas", "trabalho", "procuro", "procurando", "em", "os", "as", "para", "desenvolvedor", "engenheiro", "olá", "oi", "obrigado", "obrigada", "só", "apenas", "remotas"},
+	"en": {"job", "jobs", "role", "roles", "position", "positions", "in", "the", "for", "find", "looking", "developer", "engineer", "hello", "hi", "thanks", "only", "what", "about", "me"},
+}
+
+var letters = map[string]string{"fr": "èêëàâçœîïû", "es": "ñ¿¡", "pt": "ãõç"}
+
+// Detect guesses the language of a short message from distinctive words and
+// letters, returning "" when there is no evidence either way. It is a
+// fallback for when the LLM does not report a language.
+func Detect(text string) string {
+	lower := strings.ToLower(text)
+	words := strings.FieldsFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+
+	scores := map[string]int{}
+	for _, w := range words {
+		for lang, list := range markers {
+			for _, m := range list {
+				if w == m {
+					scores[lang]++
+				}
+			}
+		}
+	}
+	for lang, chars := range letters {
+		if strings.ContainsAny(lower, chars) {
+			scores[lang] += 2
+		}
+	}
+
+	best, bestScore := "", 0
+	for _, lang := range []string{Default, "fr", "es", "pt"} {
+		if scores[lang] > bestScore {
+			best, bestScore = lang, scores[lang]
+		}
+	}
+	return best
+}
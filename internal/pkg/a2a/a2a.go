@@ -25,21 +25,33 @@ type A2AMessage struct {
 	Kind             string                 `json:"kind"`
 }
 
+type PushNotificationAuthentication struct {
+	Schemes     []string `json:"schemes"`
+	Credentials string   `json:"credentials,omitempty"`
+}
+
 type PushNotificationConfig struct {
-	Url            string `json:"url"`
-	Token          string `json:"token omitempty"`
-	Authentication string `json:"authentication omitempty"`
+	ID             string                          `json:"id,omitempty"`
+	Url            string                          `json:"url"`
+	Token          string                          `json:"token,omitempty"`
+	Authentication *PushNotificationAuthentication `json:"authentication,omitempty"`
+}
+
+type TaskPushNotificationConfig struct {
+	TaskID                 string                 `json:"taskId"`
+	PushNotificationConfig PushNotificationConfig `json:"pushNotificationConfig"`
 }
 
 type MessageConfiguration struct {
-	Blocking               string                 `json:"blocking"`
-	AcceptedOutputModes    string                 `json:"acceptedOutputModes"`
-	PushNotificationConfig PushNotificationConfig `json:"pushNotificationConfig omitempty"`
+	Blocking               *bool                   `json:"blocking,omitempty"`
+	AcceptedOutputModes    []string                `json:"acceptedOutputModes,omitempty"`
+	HistoryLength          *int                    `json:"historyLength,omitempty"`
+	PushNotificationConfig *PushNotificationConfig `json:"pushNotificationConfig,omitempty"`
 }
 
 type MessageParams struct {
-	Message       A2AMessage           `json:"message"`
-	Configuration MessageConfiguration `json:"configuration"`
+	Message       A2AMessage            `json:"message"`
+	Configuration *MessageConfiguration `json:"configuration,omitempty"`
 }
 
 type ExecuteParams struct {
@@ -76,6 +88,12 @@ type TaskResult struct {
 	Artifacts []Artifact   `json:"artifacts"`
 	History   []A2AMessage `json:"history"`
 	Kind      string       `json:"kind"`
+	// Owner is the caller that created the task, the only one who may see
+	// or change it. It is never sent to clients.
+	Owner string `json:"-"`
+	// Pending is the server's state for the next turn of a task waiting
+	// for input. It is never sent to clients either.
+	Pending any `json:"-"`
 }
 
 type JSONRPCResponse struct {
@@ -87,17 +105,83 @@ type JSONRPCResponse struct {
 
 // Agent Card (for A2A discovery)
 type AgentCard struct {
-	Name         string            `json:"name"`
-	Description  string            `json:"description"`
-	Version      string            `json:"version"`
-	Capabilities []string          `json:"capabilities"`
-	Skills       []Skill           `json:"skills"`
-	ServiceURL   string            `json:"serviceUrl"`
-	Auth         map[string]string `json:"auth,omitempty"`
-}
-
-type Skill struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ProtocolVersion    string            `json:"protocolVersion"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description"`
+	URL                string            `json:"url"`
+	PreferredTransport string            `json:"preferredTransport,omitempty"`
+	Version            string            `json:"version"`
+	Provider           *AgentProvider    `json:"provider,omitempty"`
+	DocumentationURL   string            `json:"documentationUrl,omitempty"`
+	Capabilities       AgentCapabilities `json:"capabilities"`
+	// SecuritySchemes are keyed by name; Security lists the alternatives a
+	// caller can satisfy, each naming schemes from SecuritySchemes
+	SecuritySchemes                   map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+	Security                          []map[string][]string     `json:"security,omitempty"`
+	DefaultInputModes                 []string                  `json:"defaultInputModes"`
+	DefaultOutputModes                []string                  `json:"defaultOutputModes"`
+	Skills                            []AgentSkill              `json:"skills"`
+	SupportsAuthenticatedExtendedCard bool                      `json:"supportsAuthenticatedExtendedCard"`
+}
+
+type AgentProvider struct {
+	Organization string `json:"organization"`
+	URL          string `json:"url"`
+}
+
+type AgentCapabilities struct {
+	Streaming              bool `json:"streaming"`
+	PushNotifications      bool `json:"pushNotifications"`
+	StateTransitionHistory bool `json:"stateTransitionHistory"`
+}
+
+// SecurityScheme follows OpenAPI: Type is "apiKey" (with In and Name) or
+// "http" (with Scheme, e.g. "bearer").
+type SecurityScheme struct {
 	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Scheme      string `json:"scheme,omitempty"`
+}
+
+type AgentSkill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Examples    []string `json:"examples,omitempty"`
+	InputModes  []string `json:"inputModes,omitempty"`
+	OutputModes []string `json:"outputModes,omitempty"`
+	// RateLimit is set on skills whose use is limited
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+}
+
+// RateLimit is how often each caller, told apart by address, may use a
+// skill. It is not part of the A2A spec; clients that do not know it
+// ignore it.
+type RateLimit struct {
+	RequestsPerMinute int `json:"requestsPerMinute"`
+}
+
+// TaskStatusUpdateEvent is streamed when a task changes state. Final marks
+// the last event of the stream.
+type TaskStatusUpdateEvent struct {
+	TaskID    string                 `json:"taskId"`
+	ContextID string                 `json:"contextId"`
+	Kind      string                 `json:"kind"`
+	Status    Task                   `json:"status"`
+	Final     bool                   `json:"final"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// TaskArtifactUpdateEvent is streamed when a task produces an artifact, or
+// with Append set, more parts of one.
+type TaskArtifactUpdateEvent struct {
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Kind      string   `json:"kind"`
+	Artifact  Artifact `json:"artifact"`
+	Append    bool     `json:"append,omitempty"`
+	LastChunk bool     `json:"lastChunk,omitempty"`
 }
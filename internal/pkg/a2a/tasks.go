@@ -1,27 +1,170 @@
 package a2a
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
+// TaskStore keeps tasks by id. It stores and hands out copies, so a caller
+// can modify the task it got and Set it back without racing other readers.
+// Tasks are dropped after a while, see Retain and RetainIdle.
 type TaskStore struct {
 	mu    sync.RWMutex
-	tasks map[string]*Task
+	tasks map[string]*TaskResult
+
+	// finished holds the ids passed to Finish, oldest first
+	finished    []string
+	finishedAt  map[string]time.Time
+	ttl         time.Duration
+	maxFinished int
+	// updatedAt is when each unfinished task last changed
+	updatedAt map[string]time.Time
+	idleTTL   time.Duration
+	lastSweep time.Time
+	onEvict   func(id string)
 }
 
 func NewTaskStore() *TaskStore {
 	return &TaskStore{
-		tasks: make(map[string]*Task),
+		tasks:      make(map[string]*TaskResult),
+		finishedAt: make(map[string]time.Time),
+		updatedAt:  make(map[string]time.Time),
 	}
 }
 
-func (ts *TaskStore) Set(task *Task) {
+// Retain keeps finished tasks for ttl, and at most maxFinished of them;
+// zero means no limit. By default they are kept forever.
+func (ts *TaskStore) Retain(ttl time.Duration, maxFinished int) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.ttl, ts.maxFinished = ttl, maxFinished
+}
+
+// RetainIdle keeps tasks that never finish, such as those waiting for
+// input, for ttl after their last change; zero keeps them forever.
+func (ts *TaskStore) RetainIdle(ttl time.Duration) {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
-	ts.tasks[task.ID] = task
+	ts.idleTTL = ttl
+}
+
+// OnEvict calls fn with the id of every task dropped by the store's limits.
+func (ts *TaskStore) OnEvict(fn func(id string)) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.onEvict = fn
+}
+
+func (ts *TaskStore) Set(task *TaskResult) {
+	now := time.Now()
+	ts.mu.Lock()
+	ts.tasks[task.ID] = task.clone()
+	ts.touch(task.ID, now)
+	evicted, onEvict := ts.evict(now)
+	ts.mu.Unlock()
+	notifyEvicted(onEvict, evicted)
 }
 
-func (ts *TaskStore) Get(id string) (*Task, bool) {
+func (ts *TaskStore) Get(id string) (*TaskResult, bool) {
 	ts.mu.RLock()
 	defer ts.mu.RUnlock()
 	task, ok := ts.tasks[id]
-	return task, ok
+	if !ok || ts.expired(id, time.Now()) {
+		return nil, false
+	}
+	return task.clone(), true
+}
+
+// Update applies fn to the stored task under the store's lock and returns
+// the result. It reports false if there is no task with that id.
+func (ts *TaskStore) Update(id string, fn func(*TaskResult)) (*TaskResult, bool) {
+	now := time.Now()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	task, ok := ts.tasks[id]
+	if !ok || ts.expired(id, now) {
+		return nil, false
+	}
+	updated := task.clone()
+	fn(updated)
+	ts.tasks[id] = updated
+	ts.touch(id, now)
+	return updated.clone(), true
+}
+
+// Finish marks a task as done, starting its retention period. Finishing a
+// task twice keeps the first time.
+func (ts *TaskStore) Finish(id string) {
+	now := time.Now()
+	ts.mu.Lock()
+	if _, done := ts.finishedAt[id]; !done && ts.tasks[id] != nil {
+		ts.finished = append(ts.finished, id)
+		ts.finishedAt[id] = now
+		delete(ts.updatedAt, id)
+	}
+	evicted, onEvict := ts.evict(now)
+	ts.mu.Unlock()
+	notifyEvicted(onEvict, evicted)
+}
+
+// touch records a change to an unfinished task.
+func (ts *TaskStore) touch(id string, now time.Time) {
+	if _, done := ts.finishedAt[id]; !done {
+		ts.updatedAt[id] = now
+	}
+}
+
+func (ts *TaskStore) expired(id string, now time.Time) bool {
+	if at, done := ts.finishedAt[id]; done {
+		return ts.ttl > 0 && now.Sub(at) >= ts.ttl
+	}
+	at, ok := ts.updatedAt[id]
+	return ok && ts.idleTTL > 0 && now.Sub(at) >= ts.idleTTL
+}
+
+// evict drops finished tasks past their ttl or over maxFinished, and idle
+// unfinished ones, returning their ids and the callback to tell. Finish
+// appends in time order, so the finished tasks to drop are all at the
+// front; idle tasks are looked for at most every tenth of idleTTL.
+func (ts *TaskStore) evict(now time.Time) ([]string, func(string)) {
+	var ids []string
+	for len(ts.finished) > 0 {
+		oldest := ts.finished[0]
+		if !ts.expired(oldest, now) && (ts.maxFinished <= 0 || len(ts.finished) <= ts.maxFinished) {
+			break
+		}
+		delete(ts.tasks, oldest)
+		delete(ts.finishedAt, oldest)
+		ids = append(ids, oldest)
+		ts.finished = ts.finished[1:]
+	}
+
+	if ts.idleTTL > 0 && now.Sub(ts.lastSweep) >= ts.idleTTL/10 {
+		ts.lastSweep = now
+		for id := range ts.updatedAt {
+			if ts.expired(id, now) {
+				delete(ts.tasks, id)
+				delete(ts.updatedAt, id)
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, ts.onEvict
+}
+
+func notifyEvicted(onEvict func(string), ids []string) {
+	if onEvict == nil {
+		return
+	}
+	for _, id := range ids {
+		onEvict(id)
+	}
+}
+
+func (t *TaskResult) clone() *TaskResult {
+	c := *t
+	// Cap the slices so appends on the copy never write into shared arrays
+	c.History = t.History[:len(t.History):len(t.History)]
+	c.Artifacts = t.Artifacts[:len(t.Artifacts):len(t.Artifacts)]
+	return &c
 }
@@ -0,0 +1,94 @@
+package a2a
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTaskStoreEvictsFinishedTasks(t *testing.T) {
+	ts := NewTaskStore()
+	ts.Retain(time.Hour, 2)
+	var evicted []string
+	ts.OnEvict(func(id string) { evicted = append(evicted, id) })
+
+	for _, id := range []string{"a", "b", "c", "running"} {
+		ts.Set(&TaskResult{ID: id})
+	}
+	ts.Finish("a")
+	ts.Finish("b")
+	ts.Finish("a")
+	if len(evicted) != 0 {
+		t.Fatalf("evicted %v within the limit", evicted)
+	}
+
+	ts.Finish("c")
+	if !slices.Equal(evicted, []string{"a"}) {
+		t.Fatalf("evicted = %v, want the oldest finished task", evicted)
+	}
+	if _, ok := ts.Get("a"); ok {
+		t.Error("evicted task still stored")
+	}
+	for _, id := range []string{"b", "c", "running"} {
+		if _, ok := ts.Get(id); !ok {
+			t.Errorf("task %s was dropped", id)
+		}
+	}
+}
+
+func TestTaskStoreExpiresFinishedTasks(t *testing.T) {
+	ts := NewTaskStore()
+	ts.Retain(20*time.Millisecond, 0)
+	var evicted []string
+	ts.OnEvict(func(id string) { evicted = append(evicted, id) })
+
+	ts.Set(&TaskResult{ID: "done"})
+	ts.Set(&TaskResult{ID: "running"})
+	ts.Finish("done")
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := ts.Get("done"); ok {
+		t.Error("expired task still returned")
+	}
+	if _, ok := ts.Update("done", func(*TaskResult) {}); ok {
+		t.Error("expired task still updatable")
+	}
+	ts.Set(&TaskResult{ID: "new"})
+	if !slices.Equal(evicted, []string{"done"}) {
+		t.Errorf("evicted = %v", evicted)
+	}
+	if _, ok := ts.Get("running"); !ok {
+		t.Error("unfinished task expired")
+	}
+}
+
+func TestTaskStoreExpiresIdleTasks(t *testing.T) {
+	ts := NewTaskStore()
+	ts.Retain(time.Hour, 0)
+	ts.RetainIdle(40 * time.Millisecond)
+	var evicted []string
+	ts.OnEvict(func(id string) { evicted = append(evicted, id) })
+
+	for _, id := range []string{"waiting", "active", "done"} {
+		ts.Set(&TaskResult{ID: id})
+	}
+	ts.Finish("done")
+	time.Sleep(25 * time.Millisecond)
+	if _, ok := ts.Update("active", func(*TaskResult) {}); !ok {
+		t.Fatal("active task missing")
+	}
+	time.Sleep(25 * time.Millisecond)
+
+	if _, ok := ts.Get("waiting"); ok {
+		t.Error("idle task still returned")
+	}
+	for _, id := range []string{"active", "done"} {
+		if _, ok := ts.Get(id); !ok {
+			t.Errorf("task %s was dropped", id)
+		}
+	}
+	ts.Set(&TaskResult{ID: "new"})
+	if !slices.Equal(evicted, []string{"waiting"}) {
+		t.Errorf("evicted = %v, want the idle task", evicted)
+	}
+}
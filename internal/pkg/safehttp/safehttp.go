@@ -0,0 +1,71 @@
+// Package safehttp makes outbound requests to URLs chosen by clients, such
+// as webhooks and file URIs, without letting them reach internal services.
+package safehttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL = errors.New("url must be an absolute http or https url")
+	ErrPrivateURL = errors.New("url must not point to a private address")
+)
+
+// CheckURL rejects non-http(s) URLs and, unless allowPrivate is set, hosts
+// that are loopback or private addresses. Hostnames are checked again
+// against their resolved address when a Client dials.
+func CheckURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if allowPrivate {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateURL
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivate(ip) {
+		return ErrPrivateURL
+	}
+	return nil
+}
+
+// NewClient returns a client that does not follow redirects, which could
+// lead past CheckURL, and unless allowPrivate is set refuses to connect to
+// private addresses. It ignores HTTP(S)_PROXY: through a proxy the dial
+// check would only ever see the proxy's address.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = checkDial
+	}
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     &http.Transport{DialContext: dialer.DialContext, Proxy: nil},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+func checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isPrivate(ip) {
+		return ErrPrivateURL
+	}
+	return nil
+}
+
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast()
+}
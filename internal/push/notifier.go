@@ -0,0 +1,302 @@
+// Package push delivers A2A push notifications: task updates POSTed to
+// webhooks that clients register per task.
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/justinndidit/job-agent/internal/pkg/safehttp"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// MaxConfigsPerTask bounds the webhooks a single task can fan out to
+	MaxConfigsPerTask = 5
+
+	queueSize  = 256
+	retryDelay = time.Second
+	maxDelay   = 30 * time.Second
+)
+
+var (
+	ErrTooManyConfigs = fmt.Errorf("at most %d push notification configs per task", MaxConfigsPerTask)
+	ErrClosed         = errors.New("notifier closed")
+)
+
+// Notifier keeps each task's webhook configs and delivers task updates to
+// them from a pool of workers. Failed deliveries are retried with
+// exponential backoff, then written to the dead-letter log.
+type Notifier struct {
+	mu      sync.RWMutex
+	configs map[string][]a2a.PushNotificationConfig
+	closed  bool
+
+	queue        chan delivery
+	wg           sync.WaitGroup
+	client       *http.Client
+	maxAttempts  int
+	retryDelay   time.Duration
+	allowPrivate bool
+
+	deadLetterMu   sync.Mutex
+	deadLetterPath string
+	logger         *zerolog.Logger
+}
+
+type delivery struct {
+	config a2a.PushNotificationConfig
+	task   *a2a.TaskResult
+}
+
+// DeadLetter is a notification that could not be delivered.
+type DeadLetter struct {
+	Time     time.Time       `json:"time"`
+	TaskID   string          `json:"taskId"`
+	ConfigID string          `json:"configId"`
+	URL      string          `json:"url"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	Task     *a2a.TaskResult `json:"task"`
+}
+
+// NewNotifier starts workers delivering notifications, each tried up to
+// maxAttempts times. Undeliverable ones are appended to deadLetterPath as
+// JSON lines, or only logged when it is empty. The file holds the tasks
+// themselves, user messages included, so it is created readable by its
+// owner only. allowPrivate lets webhooks point to loopback and private
+// addresses, for local development and tests.
+func NewNotifier(workers, maxAttempts int, timeout time.Duration, deadLetterPath string, allowPrivate bool, log *zerolog.Logger) *Notifier {
+	n := &Notifier{
+		configs:        make(map[string][]a2a.PushNotificationConfig),
+		queue:          make(chan delivery, queueSize),
+		client:         safehttp.NewClient(timeout, allowPrivate),
+		maxAttempts:    max(maxAttempts, 1),
+		retryDelay:     retryDelay,
+		allowPrivate:   allowPrivate,
+		deadLetterPath: deadLetterPath,
+		logger:         log,
+	}
+	for range max(workers, 1) {
+		n.wg.Add(1)
+		go n.work()
+	}
+	return n
+}
+
+// Validate reports whether a webhook config can be registered.
+func (n *Notifier) Validate(config a2a.PushNotificationConfig) error {
+	return safehttp.CheckURL(config.Url, n.allowPrivate)
+}
+
+// Set registers a webhook for a task, replacing the one with the same id.
+// The id defaults to the task id.
+func (n *Notifier) Set(taskID string, config a2a.PushNotificationConfig) (a2a.PushNotificationConfig, error) {
+	if err := n.Validate(config); err != nil {
+		return config, err
+	}
+	if config.ID == "" {
+		config.ID = taskID
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	configs := n.configs[taskID]
+	for i, c := range configs {
+		if c.ID == config.ID {
+			configs[i] = config
+			return config, nil
+		}
+	}
+	if len(configs) >= MaxConfigsPerTask {
+		return config, ErrTooManyConfigs
+	}
+	n.configs[taskID] = append(configs, config)
+	return config, nil
+}
+
+// Get returns a task's webhook by id; an empty id picks the first one.
+func (n *Notifier) Get(taskID, configID string) (a2a.PushNotificationConfig, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, c := range n.configs[taskID] {
+		if configID == "" || c.ID == configID {
+			return c, true
+		}
+	}
+	return a2a.PushNotificationConfig{}, false
+}
+
+func (n *Notifier) List(taskID string) []a2a.PushNotificationConfig {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return append([]a2a.PushNotificationConfig{}, n.configs[taskID]...)
+}
+
+// Delete removes a task's webhook, reporting whether it existed.
+func (n *Notifier) Delete(taskID, configID string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	configs := n.configs[taskID]
+	for i, c := range configs {
+		if c.ID == configID {
+			configs = append(configs[:i:i], configs[i+1:]...)
+			if len(configs) == 0 {
+				delete(n.configs, taskID)
+			} else {
+				n.configs[taskID] = configs
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Forget drops all of a task's webhooks, once the task itself is gone.
+func (n *Notifier) Forget(taskID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.configs, taskID)
+}
+
+// Notify queues the task for delivery to each of its webhooks. It never
+// blocks: when the queue is full the notification goes to the dead-letter
+// log.
+func (n *Notifier) Notify(task *a2a.TaskResult) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return
+	}
+	for _, config := range n.configs[task.ID] {
+		d := delivery{config: config, task: task}
+		select {
+		case n.queue <- d:
+		default:
+			n.deadLetter(d, 0, errors.New("delivery queue full"))
+		}
+	}
+}
+
+// Close stops accepting notifications and waits for queued ones to be
+// delivered, or for ctx to end.
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *Notifier) work() {
+	defer n.wg.Done()
+	for d := range n.queue {
+		n.deliver(d)
+	}
+}
+
+// deliver tries a notification until it succeeds, fails permanently or runs
+// out of attempts.
+func (n *Notifier) deliver(d delivery) {
+	delay := n.retryDelay
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		var retry bool
+		if retry, err = n.post(d); err == nil {
+			n.logger.Debug().Str("task_id", d.task.ID).Str("url", d.config.Url).Int("attempt", attempt).Msg("Push notification delivered")
+			return
+		}
+		if !retry || attempt >= n.maxAttempts {
+			break
+		}
+		n.logger.Warn().Err(err).Str("task_id", d.task.ID).Int("attempt", attempt).Dur("retry_in", delay).Msg("Push notification failed, retrying")
+		time.Sleep(delay)
+		delay = min(2*delay, maxDelay)
+	}
+	n.deadLetter(d, attempt, err)
+}
+
+// post sends one notification. It reports whether a failure is worth
+// retrying: network errors, 408, 429 and 5xx responses are.
+func (n *Notifier) post(d delivery) (bool, error) {
+	body, err := json.Marshal(d.task)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest(http.MethodPost, d.config.Url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.config.Token != "" {
+		req.Header.Set("X-A2A-Notification-Token", d.config.Token)
+	}
+	if auth := d.config.Authentication; auth != nil && auth.Credentials != "" {
+		for _, scheme := range auth.Schemes {
+			if strings.EqualFold(scheme, "bearer") {
+				req.Header.Set("Authorization", "Bearer "+auth.Credentials)
+				break
+			}
+		}
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, safehttp.ErrPrivateURL), err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook responded %s", resp.Status)
+}
+
+func (n *Notifier) deadLetter(d delivery, attempts int, cause error) {
+	n.logger.Error().Err(cause).Str("task_id", d.task.ID).Str("url", d.config.Url).Int("attempts", attempts).Msg("Push notification undeliverable")
+	if n.deadLetterPath == "" {
+		return
+	}
+
+	line, err := json.Marshal(DeadLetter{
+		Time: time.Now().UTC(), TaskID: d.task.ID, ConfigID: d.config.ID, URL: d.config.Url,
+		Attempts: attempts, Error: cause.Error(), Task: d.task,
+	})
+	if err != nil {
+		return
+	}
+	n.deadLetterMu.Lock()
+	defer n.deadLetterMu.Unlock()
+	f, err := os.OpenFile(n.deadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		n.logger.Error().Err(err).Str("path", n.deadLetterPath).Msg("Failed to open dead-letter log")
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		n.logger.Error().Err(err).Str("path", n.deadLetterPath).Msg("Failed to write dead-letter log")
+	}
+}
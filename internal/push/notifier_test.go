@@ -0,0 +1,195 @@
+package push
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/pkg/a2a"
+	"github.com/rs/zerolog"
+)
+
+// receiver is a webhook that answers with statuses in turn, repeating the
+// last one, and records what it was sent.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	tasks    []a2a.TaskResult
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var task a2a.TaskResult
+	json.NewDecoder(r.Body).Decode(&task)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.tasks = append(rc.tasks, task)
+	status := rc.statuses[min(len(rc.requests), len(rc.statuses))-1]
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func newTestNotifier(t *testing.T, maxAttempts int, deadLetterPath string) *Notifier {
+	t.Helper()
+	log := zerolog.Nop()
+	n := NewNotifier(2, maxAttempts, time.Second, deadLetterPath, true, &log)
+	n.retryDelay = time.Millisecond
+	return n
+}
+
+// notify delivers one task to url and waits for the notifier to finish.
+func notify(t *testing.T, n *Notifier, config a2a.PushNotificationConfig) *a2a.TaskResult {
+	t.Helper()
+	task := &a2a.TaskResult{ID: "task-1", ContextID: "ctx-1", Kind: "task"}
+	if _, err := n.Set(task.ID, config); err != nil {
+		t.Fatal(err)
+	}
+	n.Notify(task)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestNotifierHeaders(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	n := newTestNotifier(t, 3, "")
+	notify(t, n, a2a.PushNotificationConfig{
+		Url:            srv.URL,
+		Token:          "secret-token",
+		Authentication: &a2a.PushNotificationAuthentication{Schemes: []string{"Bearer"}, Credentials: "jwt"},
+	})
+
+	if rc.count() != 1 {
+		t.Fatalf("delivered %d times, want 1", rc.count())
+	}
+	req := rc.requests[0]
+	if got := req.Header.Get("X-A2A-Notification-Token"); got != "secret-token" {
+		t.Errorf("token header = %q", got)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer jwt" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if rc.tasks[0].ID != "task-1" {
+		t.Errorf("task = %+v", rc.tasks[0])
+	}
+}
+
+func TestNotifierWithoutCredentials(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusNoContent}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	notify(t, newTestNotifier(t, 3, ""), a2a.PushNotificationConfig{Url: srv.URL})
+
+	req := rc.requests[0]
+	if req.Header.Get("X-A2A-Notification-Token") != "" || req.Header.Get("Authorization") != "" {
+		t.Errorf("unexpected auth headers %v", req.Header)
+	}
+}
+
+func TestNotifierRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		attempts  int
+		delivered bool
+	}{
+		{"5xx is retried", []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}, 3, true},
+		{"429 is retried", []int{http.StatusTooManyRequests, http.StatusOK}, 2, true},
+		{"408 is retried", []int{http.StatusRequestTimeout, http.StatusOK}, 2, true},
+		{"other 4xx are not retried", []int{http.StatusBadRequest}, 1, false},
+		{"401 is not retried", []int{http.StatusUnauthorized}, 1, false},
+		{"attempts are limited", []int{http.StatusServiceUnavailable}, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{statuses: tt.statuses}
+			srv := httptest.NewServer(rc)
+			defer srv.Close()
+			deadLetters := filepath.Join(t.TempDir(), "dead.jsonl")
+
+			notify(t, newTestNotifier(t, 4, deadLetters), a2a.PushNotificationConfig{Url: srv.URL})
+
+			if rc.count() != tt.attempts {
+				t.Errorf("attempts = %d, want %d", rc.count(), tt.attempts)
+			}
+			_, err := os.Stat(deadLetters)
+			if dead := err == nil; dead == tt.delivered {
+				t.Errorf("dead-lettered = %v, want %v", dead, !tt.delivered)
+			}
+		})
+	}
+}
+
+func TestNotifierDeadLetter(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+
+	notify(t, newTestNotifier(t, 2, path), a2a.PushNotificationConfig{ID: "hook", Url: srv.URL})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("dead-letter file mode %v, want 0600: it holds user data", perm)
+	}
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var dl DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &dl); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		letters = append(letters, dl)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("dead letters = %d, want 1", len(letters))
+	}
+	dl := letters[0]
+	if dl.TaskID != "task-1" || dl.ConfigID != "hook" || dl.URL != srv.URL || dl.Attempts != 2 || dl.Error == "" || dl.Task == nil {
+		t.Errorf("dead letter = %+v", dl)
+	}
+}
+
+func TestNotifierRejectsPrivateURLs(t *testing.T) {
+	log := zerolog.Nop()
+	n := NewNotifier(1, 1, time.Second, "", false, &log)
+	defer n.Close(context.Background())
+
+	for _, url := range []string{"http://127.0.0.1/hook", "http://localhost:8080/hook", "http://10.0.0.1/hook", "ftp://example.com/hook"} {
+		if _, err := n.Set("task-1", a2a.PushNotificationConfig{Url: url}); err == nil {
+			t.Errorf("Set(%q) accepted", url)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/justinndidit/job-agent/internal/config"
@@ -20,11 +22,23 @@ type JobScraper struct {
 }
 
 type JobQuery struct {
-	Title    string `json:"title_filter"`
-	Location string `json:"location_filter"`
+	Title                string   `json:"title_filter"`
+	Location             string   `json:"location_filter"`
+	Remote               *bool    `json:"remote,omitempty"`
+	ExcludeOrganizations []string `json:"exclude_organizations,omitempty"`
+	// Language is the user's language; Title and Location are always English
+	Language string `json:"language,omitempty"`
+	// Titles, when set, are searched instead of Title (which they include)
+	Titles []string `json:"titles,omitempty"`
+	// Page is the 1-based page of PageSize results; 0 means the first
+	Page int `json:"page,omitempty"`
 }
 
+// PageSize is how many postings one query returns.
+const PageSize = 10
+
 type JobPosting struct {
+	ID               JobID    `json:"id"`
 	Title            string   `json:"title"`
 	OrganizationUrl  string   `json:"organization_url"`
 	DatePosted       string   `json:"date_posted"`
@@ -35,6 +49,39 @@ type JobPosting struct {
 	JobLocation      []string `json:"locations_derived"`
 	TimeZone         []string `json:"timezones_derived"`
 	Remote           bool     `json:"remote_derived"`
+	Description      string   `json:"description_text,omitempty"`
+	// MatchScore (0-100) and MatchReason are set when results are ranked
+	// against a resume
+	MatchScore  int    `json:"match_score,omitempty"`
+	MatchReason string `json:"match_reason,omitempty"`
+	// Summary is an optional 2-3 bullet digest of Description
+	Summary []string `json:"summary,omitempty"`
+}
+
+// Key identifies the posting across searches: its id, or its URL when the
+// upstream API returned none.
+func (j JobPosting) Key() string {
+	if j.ID != "" {
+		return string(j.ID)
+	}
+	return j.SourceUrl
+}
+
+// JobID accepts both string and numeric ids from the upstream API.
+type JobID string
+
+func (id *JobID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = JobID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid job id %s", data)
+	}
+	*id = JobID(n.String())
+	return nil
 }
 
 func NewJobScraper(cfg config.JobScraperConfig, log *zerolog.Logger) *JobScraper {
@@ -47,16 +94,23 @@ func NewJobScraper(cfg config.JobScraperConfig, log *zerolog.Logger) *JobScraper
 
 func (s *JobScraper) QueryJobs(ctx context.Context, job *JobQuery) ([]JobPosting, error) {
 	params := url.Values{}
-	params.Add("limit", "10")
-	params.Add("offset", "0")
+	params.Add("limit", strconv.Itoa(PageSize))
+	params.Add("offset", strconv.Itoa(max(job.Page-1, 0)*PageSize))
 	params.Add("description_type", "text")
 
-	if job.Title != "" {
+	// title_filter takes a single phrase; alternatives need the advanced
+	// filter's OR syntax
+	if filter := anyTitle(job.Titles); filter != "" {
+		params.Add("advanced_title_filter", filter)
+	} else if job.Title != "" {
 		params.Add("title_filter", fmt.Sprintf("\"%s\"", job.Title))
 	}
 	if job.Location != "" {
 		params.Add("location_filter", fmt.Sprintf("\"%s\"", job.Location))
 	}
+	if job.Remote != nil {
+		params.Add("remote", strconv.FormatBool(*job.Remote))
+	}
 
 	fullURL := fmt.Sprintf("%s?%s", s.config.RAPID_API_BASE_URL, params.Encode())
 	s.logger.Info().Str("url", fullURL).Msg("Querying jobs API")
@@ -92,5 +146,58 @@ func (s *JobScraper) QueryJobs(ctx context.Context, job *JobQuery) ([]JobPosting
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return jobPostings, nil
+	return excludeOrganizations(jobPostings, job.ExcludeOrganizations), nil
+}
+
+// titleOperators are the characters with a meaning in advanced_title_filter.
+var titleOperators = strings.NewReplacer("'", " ", "|", " ", "&", " ", "!", " ", "(", " ", ")", " ", "<", " ", ">", " ", ":", " ", "*", " ")
+
+// anyTitle is an advanced_title_filter matching any of the titles, each as
+// a phrase: 'Software Engineer' | 'Backend Engineer'.
+func anyTitle(titles []string) string {
+	phrases := make([]string, 0, len(titles))
+	for _, t := range titles {
+		if t = strings.Join(strings.Fields(titleOperators.Replace(t)), " "); t != "" {
+			phrases = append(phrases, "'"+t+"'")
+		}
+	}
+	return strings.Join(phrases, " | ")
+}
+
+func excludeOrganizations(jobs []JobPosting, excluded []string) []JobPosting {
+	if len(excluded) == 0 {
+		return jobs
+	}
+
+	filtered := jobs[:0]
+	for _, job := range jobs {
+		if !isExcluded(job, excluded) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+func isExcluded(job JobPosting, organizations []string) bool {
+	org := strings.ToLower(job.Organization)
+	for _, name := range organizations {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" && strings.Contains(org, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether a posting found some other way, such as from the
+// semantic index, passes the query's location, remote and organization
+// filters the way QueryJobs results do.
+func (q JobQuery) Matches(job JobPosting) bool {
+	if q.Remote != nil && job.Remote != *q.Remote {
+		return false
+	}
+	if loc := strings.ToLower(strings.TrimSpace(q.Location)); loc != "" &&
+		!strings.Contains(strings.ToLower(strings.Join(job.JobLocation, " | ")), loc) {
+		return false
+	}
+	return !isExcluded(job, q.ExcludeOrganizations)
 }
@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/justinndidit/job-agent/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestAnyTitle(t *testing.T) {
+	tests := []struct {
+		titles []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"Software Engineer"}, "'Software Engineer'"},
+		{[]string{"Software Engineer", "Backend Engineer"}, "'Software Engineer' | 'Backend Engineer'"},
+		{[]string{"R&D Engineer", "Dev's (Ops)", "  ", "C++ | Go"}, "'R D Engineer' | 'Dev s Ops' | 'C++ Go'"},
+	}
+	for _, tt := range tests {
+		if got := anyTitle(tt.titles); got != tt.want {
+			t.Errorf("anyTitle(%q) = %q, want %q", tt.titles, got, tt.want)
+		}
+	}
+}
+
+func TestQueryJobsTitleFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query JobQuery
+		want  url.Values
+	}{
+		{
+			name:  "one title",
+			query: JobQuery{Title: "Software Engineer"},
+			want:  url.Values{"title_filter": {`"Software Engineer"`}},
+		},
+		{
+			name:  "expanded titles",
+			query: JobQuery{Title: "SWE", Titles: []string{"Software Engineer", "Backend Engineer"}},
+			want:  url.Values{"advanced_title_filter": {"'Software Engineer' | 'Backend Engineer'"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got url.Values
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query()
+				w.Write([]byte("[]"))
+			}))
+			defer srv.Close()
+
+			log := zerolog.Nop()
+			s := NewJobScraper(config.JobScraperConfig{RAPID_API_BASE_URL: srv.URL}, &log)
+			if _, err := s.QueryJobs(context.Background(), &tt.query); err != nil {
+				t.Fatal(err)
+			}
+			for _, param := range []string{"title_filter", "advanced_title_filter"} {
+				if got.Get(param) != tt.want.Get(param) {
+					t.Errorf("%s = %q, want %q", param, got.Get(param), tt.want.Get(param))
+				}
+			}
+		})
+	}
+}
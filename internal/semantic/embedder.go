@@ -0,0 +1,118 @@
+// Package semantic keeps every job posting the agent has seen in a local
+// vector index so postings can be found by meaning rather than title.
+package semantic
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Embedder turns texts into vectors of a fixed size. Name identifies the
+// embedding space so a saved index is not mixed with another embedder's
+// vectors.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Name() string
+}
+
+const DefaultHashDims = 1024
+
+// HashingEmbedder is an offline embedder: words and word pairs are hashed
+// into a fixed number of buckets with log-scaled counts, and the vector is
+// L2-normalized so a dot product is the cosine similarity. It captures shared
+// vocabulary, not synonyms.
+type HashingEmbedder struct {
+	dims int
+}
+
+func NewHashingEmbedder(dims int) *HashingEmbedder {
+	if dims <= 0 {
+		dims = DefaultHashDims
+	}
+	return &HashingEmbedder{dims: dims}
+}
+
+func (e *HashingEmbedder) Name() string {
+	return "hashing"
+}
+
+func (e *HashingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = e.embed(text)
+	}
+	return out, nil
+}
+
+func (e *HashingEmbedder) embed(text string) []float32 {
+	counts := make(map[int]float64)
+	var prev string
+	for _, tok := range tokenize(text) {
+		counts[e.bucket(tok)]++
+		if prev != "" {
+			// Pairs weigh less than single words
+			counts[e.bucket(prev+" "+tok)] += 0.5
+		}
+		prev = tok
+	}
+
+	vec := make([]float32, e.dims)
+	var norm float64
+	for b, c := range counts {
+		w := 1 + math.Log(c)
+		if c < 1 {
+			w = c
+		}
+		vec[b] = float32(w)
+		norm += w * w
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+func (e *HashingEmbedder) bucket(token string) int {
+	h := fnv.New32a()
+	h.Write([]byte(token))
+	return int(h.Sum32() % uint32(e.dims))
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "to": true, "in": true, "on": true,
+	"for": true, "with": true, "at": true, "by": true, "or": true, "is": true, "are": true, "be": true,
+	"we": true, "you": true, "our": true, "your": true, "will": true, "as": true, "that": true, "this": true,
+	"roles": true, "role": true, "jobs": true, "job": true, "working": true, "work": true,
+}
+
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if len(f) > 1 && !stopWords[f] {
+			tokens = append(tokens, stem(f))
+		}
+	}
+	return tokens
+}
+
+// stem strips a few common English suffixes so "payments" matches "payment".
+func stem(w string) string {
+	for _, suffix := range []string{"ing", "ies", "es", "s"} {
+		if len(w) > len(suffix)+3 && strings.HasSuffix(w, suffix) {
+			if suffix == "ies" {
+				return w[:len(w)-3] + "y"
+			}
+			return w[:len(w)-len(suffix)]
+		}
+	}
+	return w
+}
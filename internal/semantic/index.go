@@ -0,0 +1,306 @@
+package semantic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinndidit/job-agent/internal/scraper"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultMaxEntries = 50000
+	DefaultMinScore   = 0.25
+
+	maxEmbedChars = 4000
+)
+
+type entry struct {
+	Job     scraper.JobPosting `json:"job"`
+	Vector  []float32          `json:"vector"`
+	AddedAt time.Time          `json:"addedAt"`
+}
+
+type fileHeader struct {
+	Embedder string `json:"embedder"`
+	Count    int    `json:"count"`
+}
+
+// Match is a posting found by similarity; Score is the cosine similarity.
+type Match struct {
+	Job   scraper.JobPosting `json:"job"`
+	Score float64            `json:"score"`
+}
+
+type Stats struct {
+	Embedder string    `json:"embedder"`
+	Entries  int       `json:"entries"`
+	Max      int       `json:"max"`
+	Oldest   time.Time `json:"oldest,omitempty"`
+	Path     string    `json:"path,omitempty"`
+}
+
+// Index is an in-memory vector index of postings, keyed by posting, with
+// optional persistence to a JSON-lines file. When full, the oldest postings
+// are evicted first.
+type Index struct {
+	embedder Embedder
+	path     string
+	max      int
+	mu       sync.RWMutex
+	entries  map[string]*entry
+	logger   *zerolog.Logger
+}
+
+func NewIndex(embedder Embedder, path string, max int, log *zerolog.Logger) *Index {
+	if max <= 0 {
+		max = DefaultMaxEntries
+	}
+	return &Index{embedder: embedder, path: path, max: max, entries: make(map[string]*entry), logger: log}
+}
+
+// Text is what gets embedded for a posting.
+func Text(job scraper.JobPosting) string {
+	text := job.Title + ". " + job.Organization + ". " + strings.Join(job.JobLocation, ", ") + ". " + job.Description
+	if r := []rune(text); len(r) > maxEmbedChars {
+		text = string(r[:maxEmbedChars])
+	}
+	return text
+}
+
+// Add embeds and stores the postings not already in the index.
+func (x *Index) Add(ctx context.Context, jobs []scraper.JobPosting) error {
+	var fresh []scraper.JobPosting
+	x.mu.RLock()
+	for _, job := range jobs {
+		if key := job.Key(); key != "" && x.entries[key] == nil {
+			fresh = append(fresh, job)
+		}
+	}
+	x.mu.RUnlock()
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(fresh))
+	for i, job := range fresh {
+		texts[i] = Text(job)
+	}
+	vectors, err := x.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed postings: %w", err)
+	}
+	if len(vectors) != len(fresh) {
+		return fmt.Errorf("embedder returned %d vectors for %d postings", len(vectors), len(fresh))
+	}
+
+	now := time.Now().UTC()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for i, job := range fresh {
+		// Ranking and summaries are per request, not part of the posting
+		job.MatchScore, job.MatchReason, job.Summary = 0, "", nil
+		x.entries[job.Key()] = &entry{Job: job, Vector: vectors[i], AddedAt: now}
+	}
+	x.evict()
+	return nil
+}
+
+func (x *Index) evict() {
+	if len(x.entries) <= x.max {
+		return
+	}
+	keys := make([]string, 0, len(x.entries))
+	for k := range x.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return x.entries[keys[i]].AddedAt.Before(x.entries[keys[j]].AddedAt) })
+	for _, k := range keys[:len(keys)-x.max] {
+		delete(x.entries, k)
+	}
+}
+
+// Search returns up to k postings whose similarity to text is at least
+// minScore, best first.
+func (x *Index) Search(ctx context.Context, text string, k int, minScore float64) ([]Match, error) {
+	vectors, err := x.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("embedder returned no vector for the query")
+	}
+	query := vectors[0]
+
+	x.mu.RLock()
+	matches := make([]Match, 0, k)
+	for _, e := range x.entries {
+		if score := cosine(query, e.Vector); score >= minScore {
+			matches = append(matches, Match{Job: e.Job, Score: score})
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func (x *Index) Stats() Stats {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	stats := Stats{Embedder: x.embedder.Name(), Entries: len(x.entries), Max: x.max, Path: x.path}
+	for _, e := range x.entries {
+		if stats.Oldest.IsZero() || e.AddedAt.Before(stats.Oldest) {
+			stats.Oldest = e.AddedAt
+		}
+	}
+	return stats
+}
+
+// Load reads the index file, if any. Postings saved with a different
+// embedder are re-embedded.
+func (x *Index) Load(ctx context.Context) error {
+	if x.path == "" {
+		return nil
+	}
+	f, err := os.Open(x.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open index: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1<<20), 64<<20)
+	if !scanner.Scan() {
+		return scanner.Err()
+	}
+	var header fileHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return fmt.Errorf("invalid index header: %w", err)
+	}
+
+	var stale []scraper.JobPosting
+	loaded := make(map[string]*entry, header.Count)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("invalid index entry: %w", err)
+		}
+		if header.Embedder != x.embedder.Name() {
+			stale = append(stale, e.Job)
+			continue
+		}
+		loaded[e.Job.Key()] = &e
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+
+	x.mu.Lock()
+	x.entries = loaded
+	x.evict()
+	x.mu.Unlock()
+
+	if len(stale) > 0 {
+		x.logger.Info().Str("from", header.Embedder).Str("to", x.embedder.Name()).Int("postings", len(stale)).Msg("Re-embedding saved postings")
+		if err := x.Add(ctx, stale); err != nil {
+			return err
+		}
+	}
+	x.logger.Info().Int("entries", len(x.entries)).Str("path", x.path).Msg("Loaded semantic index")
+	return nil
+}
+
+// Save writes the index atomically to its file.
+func (x *Index) Save() error {
+	if x.path == "" {
+		return nil
+	}
+	tmp := x.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create index file: %w", err)
+	}
+
+	x.mu.RLock()
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(fileHeader{Embedder: x.embedder.Name(), Count: len(x.entries)})
+	for _, e := range x.entries {
+		if err != nil {
+			break
+		}
+		err = enc.Encode(e)
+	}
+	x.mu.RUnlock()
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write index: %w", err)
+	}
+	return os.Rename(tmp, x.path)
+}
+
+// JobSearcher matches agent.JobSearcher.
+type JobSearcher interface {
+	QueryJobs(ctx context.Context, job *scraper.JobQuery) ([]scraper.JobPosting, error)
+}
+
+// IndexingSearcher adds every posting returned by the wrapped searcher to
+// the index.
+type IndexingSearcher struct {
+	next  JobSearcher
+	index *Index
+}
+
+func (x *Index) Wrap(next JobSearcher) *IndexingSearcher {
+	return &IndexingSearcher{next: next, index: x}
+}
+
+func (s *IndexingSearcher) QueryJobs(ctx context.Context, job *scraper.JobQuery) ([]scraper.JobPosting, error) {
+	jobs, err := s.next.QueryJobs(ctx, job)
+	if err == nil && len(jobs) > 0 {
+		if err := s.index.Add(ctx, jobs); err != nil {
+			// Indexing is best effort; the search itself succeeded
+			s.index.logger.Warn().Err(err).Msg("Failed to index postings")
+		}
+	}
+	return jobs, err
+}
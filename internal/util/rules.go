@@ -0,0 +1,142 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	locationMarker = regexp.MustCompile(`(?i)\b(?:in|near|around|at|based in)\s+`)
+	nonWord        = regexp.MustCompile(`[^\p{L}\p{N}+#/&\- ]+`)
+
+	locationAbbreviations = map[string]string{
+		"ny": "New York", "nyc": "New York", "sf": "San Francisco", "la": "Los Angeles",
+		"ca": "California", "tx": "Texas", "wa": "Washington", "dc": "Washington",
+		"uk": "United Kingdom", "us": "United States", "usa": "United States",
+		"uae": "United Arab Emirates",
+	}
+
+	fillerWords = map[string]bool{
+		"hi": true, "hello": true, "hey": true, "yo": true, "please": true, "pls": true,
+		"can": true, "could": true, "you": true, "i": true, "im": true, "i'm": true, "me": true, "my": true,
+		"find": true, "search": true, "show": true, "get": true, "list": true, "give": true,
+		"looking": true, "look": true, "for": true, "want": true, "need": true, "any": true, "some": true,
+		"a": true, "an": true, "the": true, "there": true, "are": true, "what": true, "of": true,
+		"job": true, "jobs": true, "role": true, "roles": true, "position": true, "positions": true,
+		"vacancy": true, "vacancies": true, "opening": true, "openings": true, "gig": true, "gigs": true,
+		"work": true, "opportunities": true, "opportunity": true, "hiring": true, "available": true,
+		"remote": true, "needed": true, "wanted": true,
+	}
+
+	jobWords = map[string]bool{
+		"job": true, "jobs": true, "role": true, "roles": true, "position": true, "positions": true,
+		"vacancy": true, "vacancies": true, "opening": true, "openings": true, "gig": true, "gigs": true,
+		"hiring": true, "career": true, "careers": true, "work": true,
+	}
+
+	locationTrailers = map[string]bool{"please": true, "area": true, "pls": true, "now": true, "today": true}
+)
+
+// RuleBasedParse is the last-resort extractor used when every model in the
+// fallback chain is unavailable. It returns the same "title: ..., location:
+// ..." format as the LLM, or "invalid".
+func RuleBasedParse(input string) string {
+	text := strings.TrimSpace(nonWord.ReplaceAllString(input, " "))
+	lower := strings.ToLower(text)
+
+	var title, location string
+	if loc := locationMarker.FindAllStringIndex(text, -1); len(loc) > 0 {
+		last := loc[len(loc)-1]
+		location = cleanLocation(text[last[1]:])
+		text = text[:last[0]]
+	}
+
+	jobWord := false
+	var titleWords []string
+	for _, w := range strings.Fields(text) {
+		lw := strings.ToLower(w)
+		if jobWords[lw] {
+			jobWord = true
+		}
+		if fillerWords[lw] {
+			continue
+		}
+		if expanded, ok := locationAbbreviations[lw]; ok && location == "" {
+			location = expanded
+			continue
+		}
+		titleWords = append(titleWords, lw)
+	}
+	title = strings.Join(titleWords, " ")
+
+	if location == "" && strings.Contains(" "+lower+" ", " remote ") {
+		location = "Remote"
+	}
+	// Without a job word or a location, there's nothing to anchor on
+	if (title == "" && location == "") || (!jobWord && location == "") {
+		return "invalid"
+	}
+	return "title: " + title + ", location: " + location
+}
+
+func cleanLocation(s string) string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		if locationTrailers[strings.ToLower(w)] {
+			break
+		}
+		words = append(words, w)
+	}
+	// "Austin TX" → "Austin"
+	if n := len(words); n > 1 && len(words[n-1]) == 2 {
+		words = words[:n-1]
+	}
+	if len(words) == 1 {
+		if expanded, ok := locationAbbreviations[strings.ToLower(words[0])]; ok {
+			return expanded
+		}
+	}
+	for i, w := range words {
+		if len(w) > 0 {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+var (
+	insteadPattern = regexp.MustCompile(`(?i)\b(?:in|to|at)\s+([\p{L} ]+?)\s*(?:instead|rather)?\s*$`)
+	excludePattern = regexp.MustCompile(`(?i)\b(?:exclude|excluding|except|without|not|no)\s+([\p{L}0-9&. ]+)$`)
+	includePattern = regexp.MustCompile(`(?i)\binclude\s+([\p{L}0-9&. ]+)$`)
+)
+
+// RuleBasedDelta is the refinement counterpart of RuleBasedParse.
+func RuleBasedDelta(input string) string {
+	text := strings.TrimSpace(strings.Trim(input, "?!. "))
+	lower := strings.ToLower(text)
+
+	var fields []string
+	switch {
+	case strings.Contains(lower, "remote"):
+		if strings.Contains(lower, "not remote") || strings.Contains(lower, "on-site") || strings.Contains(lower, "onsite") {
+			fields = append(fields, "remote: false")
+		} else {
+			fields = append(fields, "remote: true")
+		}
+	case strings.Contains(lower, "on-site") || strings.Contains(lower, "onsite") || strings.Contains(lower, "in office"):
+		fields = append(fields, "remote: false")
+	}
+
+	if m := excludePattern.FindStringSubmatch(text); m != nil && !strings.Contains(strings.ToLower(m[1]), "remote") {
+		fields = append(fields, "exclude: "+strings.TrimSpace(m[1]))
+	} else if m := includePattern.FindStringSubmatch(text); m != nil {
+		fields = append(fields, "include: "+strings.TrimSpace(m[1]))
+	} else if m := insteadPattern.FindStringSubmatch(text); m != nil && !strings.Contains(strings.ToLower(m[1]), "remote") {
+		fields = append(fields, "location: "+cleanLocation(m[1]))
+	}
+
+	if len(fields) == 0 {
+		return "invalid"
+	}
+	return strings.Join(fields, ", ")
+}
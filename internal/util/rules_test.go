@@ -0,0 +1,49 @@
+package util
+
+import "testing"
+
+func TestRuleBasedParse(t *testing.T) {
+	tests := []struct{ input, want string }{
+		{"software engineer jobs in SF", "title: software engineer, location: San Francisco"},
+		{"data analyst roles in NY", "title: data analyst, location: New York"},
+		{"Hi! Could you please find me data scientist jobs in Austin, TX?", "title: data scientist, location: Austin"},
+		{"nurse vacancies in lagos please", "title: nurse, location: Lagos"},
+		{"ux designer LA", "title: ux designer, location: Los Angeles"},
+		{"remote devops engineer jobs", "title: devops engineer, location: Remote"},
+		{"what jobs are there in Berlin", "title: , location: Berlin"},
+		{"looking for a product manager position", "title: product manager, location: "},
+		{"hello", "invalid"},
+		{"how's the weather today?", "invalid"},
+		{"write me a python script to sort a list", "invalid"},
+	}
+	for _, tt := range tests {
+		if got := RuleBasedParse(tt.input); got != tt.want {
+			t.Errorf("RuleBasedParse(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRuleBasedParseReadsBack(t *testing.T) {
+	q := ParseMessage(RuleBasedParse("senior backend engineer jobs in Toronto"))
+	if q.Title != "senior backend engineer" || q.Location != "Toronto" {
+		t.Errorf("parsed %+v", q)
+	}
+}
+
+func TestRuleBasedDelta(t *testing.T) {
+	tests := []struct{ input, want string }{
+		{"only remote ones", "remote: true"},
+		{"not remote please", "remote: false"},
+		{"on-site only", "remote: false"},
+		{"what about in Toronto instead?", "location: Toronto"},
+		{"exclude Amazon", "exclude: Amazon"},
+		{"remote, without Acme Corp.", "remote: true, exclude: Acme Corp"},
+		{"include Google", "include: Google"},
+		{"thanks!", "invalid"},
+	}
+	for _, tt := range tests {
+		if got := RuleBasedDelta(tt.input); got != tt.want {
+			t.Errorf("RuleBasedDelta(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -6,6 +6,17 @@ import (
 	"github.com/justinndidit/job-agent/internal/scraper"
 )
 
+// QueryDelta is a follow-up instruction relative to the previous query in a
+// conversation. Empty fields leave the previous value untouched.
+type QueryDelta struct {
+	New      bool
+	Title    string
+	Location string
+	Remote   string // "true", "false", "any" or empty
+	Exclude  []string
+	Include  []string
+}
+
 func ParseMessage(msg string) scraper.JobQuery {
 	query := scraper.JobQuery{}
 	msg = strings.TrimSpace(msg)
@@ -31,8 +42,138 @@ func ParseMessage(msg string) scraper.JobQuery {
 			query.Title = value
 		case "location":
 			query.Location = value
+		case "remote":
+			query.Remote = parseBool(value)
+		case "exclude":
+			query.ExcludeOrganizations = splitList(value)
+		case "language":
+			query.Language = strings.ToLower(value)
 		}
 	}
 
 	return query
 }
+
+// ParseDelta reads the refinement format produced by the LLM, e.g.
+// "remote: true, exclude: Amazon; Meta" or "new, title: nurse, location: Lagos".
+func ParseDelta(msg string) QueryDelta {
+	delta := QueryDelta{}
+	for _, field := range strings.Split(strings.TrimSpace(msg), ",") {
+		field = strings.TrimSpace(field)
+		if strings.EqualFold(field, "new") {
+			delta.New = true
+			continue
+		}
+
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(strings.ToLower(parts[0])) {
+		case "title":
+			delta.Title = value
+		case "location":
+			delta.Location = value
+		case "remote":
+			delta.Remote = strings.ToLower(value)
+		case "exclude":
+			delta.Exclude = splitList(value)
+		case "include":
+			delta.Include = splitList(value)
+		}
+	}
+	return delta
+}
+
+func ApplyDelta(prev scraper.JobQuery, delta QueryDelta) scraper.JobQuery {
+	query := prev
+	query.ExcludeOrganizations = append([]string(nil), prev.ExcludeOrganizations...)
+	if delta.New {
+		query = scraper.JobQuery{}
+	}
+
+	if delta.Title != "" {
+		query.Title = delta.Title
+	}
+	if delta.Location != "" {
+		if strings.EqualFold(delta.Location, "any") {
+			query.Location = ""
+		} else {
+			query.Location = delta.Location
+		}
+	}
+	switch delta.Remote {
+	case "true", "false":
+		query.Remote = parseBool(delta.Remote)
+	case "any":
+		query.Remote = nil
+	}
+
+	for _, name := range delta.Exclude {
+		if !containsFold(query.ExcludeOrganizations, name) {
+			query.ExcludeOrganizations = append(query.ExcludeOrganizations, name)
+		}
+	}
+	if len(delta.Include) > 0 {
+		kept := query.ExcludeOrganizations[:0]
+		for _, name := range query.ExcludeOrganizations {
+			if !containsFold(delta.Include, name) {
+				kept = append(kept, name)
+			}
+		}
+		query.ExcludeOrganizations = kept
+	}
+	return query
+}
+
+// FormatQuery renders q in the same "key: value" format ParseMessage reads.
+func FormatQuery(q scraper.JobQuery) string {
+	fields := []string{"title: " + q.Title, "location: " + q.Location}
+	if q.Remote != nil {
+		if *q.Remote {
+			fields = append(fields, "remote: true")
+		} else {
+			fields = append(fields, "remote: false")
+		}
+	}
+	if len(q.ExcludeOrganizations) > 0 {
+		fields = append(fields, "exclude: "+strings.Join(q.ExcludeOrganizations, "; "))
+	}
+	if q.Language != "" {
+		fields = append(fields, "language: "+q.Language)
+	}
+	return strings.Join(fields, ", ")
+}
+
+func parseBool(value string) *bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes":
+		b := true
+		return &b
+	case "false", "no":
+		b := false
+		return &b
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}